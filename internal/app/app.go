@@ -0,0 +1,174 @@
+// Package app is the composition root: it builds the services and use cases
+// on top of the storage adapters and runs the background jobs.
+package app
+
+import (
+	"chat-app/internal/application/jobs"
+	"chat-app/internal/application/usecases"
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"chat-app/internal/infrastructure/memory"
+	"context"
+	"time"
+)
+
+// Repositories are the storage adapters the application runs on.
+type Repositories struct {
+	User        repositories.UserRepository
+	Chat        repositories.ChatRepository
+	Message     repositories.MessageRepository
+	Session     repositories.SessionRepository
+	Invite      repositories.InviteRepository
+	Audit       repositories.AuditRepository
+	Events      repositories.EventPublisher
+	Restriction repositories.RestrictionRepository
+	Contact     repositories.ContactRepository
+	Export      repositories.ExportRepository
+	Token       repositories.TokenRepository
+	TwoFactor   repositories.TwoFactorRepository
+	Mailer      repositories.Mailer
+	// LoginAttempts is kept in memory, per instance, if nil.
+	LoginAttempts repositories.LoginAttemptStore
+	// RateLimits is kept in memory, per instance, if nil.
+	RateLimits repositories.RateLimitStore
+}
+
+type Config struct {
+	BaseURL                  string // used in links sent by mail
+	TokenSecret              []byte
+	TwoFactorIssuer          string
+	TwoFactorRequired        bool
+	RequireContactAcceptance bool
+	DeletedUserMessages      domain.DeletedUserMessages // KeepMessages if zero
+	Schedule                 Schedule                   // DefaultSchedule if zero
+}
+
+// Schedule sets how often each background job runs.
+type Schedule struct {
+	PurgeDeletedChats        time.Duration
+	PurgeExpiredRestrictions time.Duration
+	PurgeDeletedUsers        time.Duration
+	ProcessPendingExports    time.Duration
+	PurgeExpiredExports      time.Duration
+	EvictIdleRecords         time.Duration
+}
+
+var DefaultSchedule = Schedule{
+	PurgeDeletedChats:        time.Hour,
+	PurgeExpiredRestrictions: time.Minute,
+	PurgeDeletedUsers:        time.Hour,
+	ProcessPendingExports:    time.Minute,
+	PurgeExpiredExports:      time.Hour,
+	EvictIdleRecords:         10 * time.Minute,
+}
+
+// idleRecordAge is how long in-memory rate limit buckets and login records
+// are kept after their last use. It is longer than every default window,
+// lockout and refill time.
+const idleRecordAge = 24 * time.Hour
+
+// idleEvicter is implemented by stores that do not expire records on their
+// own, such as the in-memory ones.
+type idleEvicter interface {
+	DeleteIdle(before time.Time) int
+}
+
+type App struct {
+	UserManagement       *usecases.UserManagement
+	ChatManagement       *usecases.ChatManagement
+	ContactManagement    *usecases.ContactManagement
+	Messaging            *usecases.Messaging
+	AccountVerification  *usecases.AccountVerification
+	DataExportManagement *usecases.DataExportManagement
+	Maintenance          *usecases.Maintenance
+	// Administration does not check the caller; only expose it to operators.
+	Administration *usecases.Administration
+
+	MessageService *services.MessageService
+	Schedule       Schedule
+
+	evicters []idleEvicter
+}
+
+func New(repos Repositories, config Config) *App {
+	if config.Schedule == (Schedule{}) {
+		config.Schedule = DefaultSchedule
+	}
+	if repos.LoginAttempts == nil {
+		repos.LoginAttempts = memory.NewLoginAttemptStore()
+	}
+	if repos.RateLimits == nil {
+		repos.RateLimits = memory.NewRateLimitStore()
+	}
+	a := &App{Schedule: config.Schedule}
+	for _, store := range []any{repos.LoginAttempts, repos.RateLimits} {
+		if evicter, ok := store.(idleEvicter); ok {
+			a.evicters = append(a.evicters, evicter)
+		}
+	}
+
+	userService := services.NewUserService(repos.User)
+	chatService := services.NewChatService(repos.Chat)
+	messageService := services.NewMessageService(repos.Message, services.NewMessageIndex())
+	sessionService := services.NewSessionService(repos.Session)
+	inviteService := services.NewInviteService(repos.Invite)
+	auditService := services.NewAuditService(repos.Audit, repos.Events)
+	moderationService := services.NewModerationService(repos.Restriction)
+	contactService := services.NewContactService(repos.Contact, config.RequireContactAcceptance)
+	exportService := services.NewExportService(repos.Export)
+	tokenService := services.NewTokenService(repos.Token, config.TokenSecret)
+	twoFactorService := services.NewTwoFactorService(repos.TwoFactor, config.TwoFactorIssuer, config.TwoFactorRequired)
+	loginGuard := services.NewLoginGuardService(repos.LoginAttempts)
+	rateLimiter := services.NewRateLimiter(repos.RateLimits)
+
+	a.MessageService = messageService
+	a.ChatManagement = usecases.NewChatManagement(chatService, sessionService, userService, inviteService, auditService, moderationService, rateLimiter)
+	a.UserManagement = usecases.NewUserManagement(userService, chatService, sessionService, twoFactorService, loginGuard, rateLimiter, repos.Mailer)
+	a.ContactManagement = usecases.NewContactManagement(userService, contactService, sessionService)
+	a.Messaging = usecases.NewMessaging(chatService, messageService, sessionService, moderationService, userService, rateLimiter)
+	a.AccountVerification = usecases.NewAccountVerification(userService, sessionService, tokenService, repos.Mailer, config.BaseURL)
+	a.DataExportManagement = usecases.NewDataExportManagement(userService, chatService, messageService, sessionService, exportService)
+	a.Maintenance = usecases.NewMaintenance(chatService, messageService, moderationService, userService, sessionService, contactService, a.ChatManagement)
+	if config.DeletedUserMessages != 0 {
+		a.Maintenance.DeletedUserMessages = config.DeletedUserMessages
+	}
+	a.Administration = usecases.NewAdministration(loginGuard)
+	return a
+}
+
+// Start rebuilds the search index and then starts the background jobs,
+// which run until ctx is cancelled. Job errors are handed to onError, which
+// may be nil. Call it once, before serving requests.
+func (a *App) Start(ctx context.Context, onError func(error)) error {
+	if _, err := a.MessageService.RebuildIndex(ctx); err != nil {
+		return err
+	}
+
+	schedule := []struct {
+		interval time.Duration
+		job      func(context.Context) error
+	}{
+		{a.Schedule.PurgeDeletedChats, a.Maintenance.PurgeDeletedChats},
+		{a.Schedule.PurgeExpiredRestrictions, a.Maintenance.PurgeExpiredRestrictions},
+		{a.Schedule.PurgeDeletedUsers, a.Maintenance.PurgeDeletedUsers},
+		{a.Schedule.ProcessPendingExports, a.DataExportManagement.ProcessPendingExports},
+		{a.Schedule.PurgeExpiredExports, a.DataExportManagement.PurgeExpiredExports},
+		{a.Schedule.EvictIdleRecords, a.evictIdleRecords},
+	}
+	for _, s := range schedule {
+		if s.interval <= 0 {
+			continue
+		}
+		go jobs.Every(ctx, s.interval, s.job, onError)
+	}
+	return nil
+}
+
+func (a *App) evictIdleRecords(context.Context) error {
+	before := time.Now().Add(-idleRecordAge)
+	for _, evicter := range a.evicters {
+		evicter.DeleteIdle(before)
+	}
+	return nil
+}
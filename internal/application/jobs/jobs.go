@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Every runs job once per interval until ctx is cancelled. Errors are handed
+// to onError, which may be nil, and never stop the loop.
+func Every(ctx context.Context, interval time.Duration, job func(context.Context) error, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+// AccountVerification handles the flows that prove a user owns their email
+// address: verifying it and resetting a forgotten password. Links in the
+// mails point at BaseURL.
+type AccountVerification struct {
+	UserService    *services.UserService
+	SessionService *services.SessionService
+	TokenService   *services.TokenService
+	Mailer         repositories.Mailer
+	BaseURL        string
+}
+
+func NewAccountVerification(userService *services.UserService, sessionService *services.SessionService, tokenService *services.TokenService, mailer repositories.Mailer, baseURL string) *AccountVerification {
+	return &AccountVerification{
+		UserService:    userService,
+		SessionService: sessionService,
+		TokenService:   tokenService,
+		Mailer:         mailer,
+		BaseURL:        baseURL,
+	}
+}
+
+// RequestEmailVerification mails the caller a link to verify their address.
+func (av *AccountVerification) RequestEmailVerification(ctx context.Context, sessionID domain.ID) error {
+	session, err := av.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	user, err := av.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedTime != nil {
+		return domain.Conflict("email is already verified")
+	}
+
+	token, err := av.TokenService.Issue(ctx, services.TokenVerifyEmail, user.ID, user.Email, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return av.Mailer.Send(ctx, domain.Mail{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to verify your email address:\n%s\n\nThe link expires in %v.\n",
+			user.DisplayName(), av.link("verify-email", token), emailVerificationTTL),
+	})
+}
+
+func (av *AccountVerification) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := av.TokenService.Consume(ctx, services.TokenVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	return av.UserService.MarkEmailVerified(ctx, claims.UserID, claims.Subject)
+}
+
+// RequestPasswordReset mails a reset link if an account uses the address.
+// It succeeds either way so that it cannot be used to probe for accounts.
+func (av *AccountVerification) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := av.UserService.FindUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.DeletedTime != nil {
+		return nil
+	}
+
+	token, err := av.TokenService.Issue(ctx, services.TokenResetPassword, user.ID, passwordResetSubject(user), passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return av.Mailer.Send(ctx, domain.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to choose a new password:\n%s\n\nThe link expires in %v. If you did not ask for this, you can ignore this mail.\n",
+			user.DisplayName(), av.link("reset-password", token), passwordResetTTL),
+	})
+}
+
+// ResetPassword sets a new password using a token from RequestPasswordReset.
+// The password is checked first so that a rejected one does not use up the
+// token. The token only works once, and not at all if the user's email or
+// password changed after it was issued.
+func (av *AccountVerification) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := services.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+	claims, err := av.TokenService.Consume(ctx, services.TokenResetPassword, token)
+	if err != nil {
+		return err
+	}
+	user, err := av.UserService.GetUserInfo(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.Subject != passwordResetSubject(user) {
+		return repositories.ErrInvalidToken
+	}
+	return av.UserService.ResetPassword(ctx, claims.UserID, newPassword)
+}
+
+// passwordResetSubject binds a reset token to the user's email and to when
+// their password last changed. The time only has digits, so it comes first.
+func passwordResetSubject(user domain.User) string {
+	var changed string
+	if user.PasswordChangedTime != nil {
+		changed = strconv.FormatInt(user.PasswordChangedTime.UnixNano(), 10)
+	}
+	return changed + "|" + user.Email
+}
+
+func (av *AccountVerification) link(path, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", av.BaseURL, path, url.QueryEscape(token))
+}
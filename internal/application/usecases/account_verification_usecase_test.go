@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"chat-app/internal/infrastructure/mail"
+	"chat-app/internal/infrastructure/memory"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const newPassword = "battery staple 2"
+
+func newAccountVerification(f *fixture) (*AccountVerification, *mail.MemorySink) {
+	sink := mail.NewMemorySink()
+	tokens := services.NewTokenService(memory.NewTokenRepository(), []byte("test secret"))
+	return NewAccountVerification(f.userService, f.sessionService, tokens, sink, "https://chat.example.com"), sink
+}
+
+// requestReset asks for a reset link for the user and returns its token.
+func requestReset(t *testing.T, f *fixture, av *AccountVerification, sink *mail.MemorySink, email string) string {
+	t.Helper()
+	if err := av.RequestPasswordReset(f.ctx, email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	sent := sink.Sent()
+	if len(sent) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	body := sent[len(sent)-1].Body
+	start := strings.Index(body, "https://chat.example.com/reset-password?")
+	if start < 0 {
+		t.Fatalf("mail has no reset link: %q", body)
+	}
+	link, err := url.Parse(strings.Fields(body[start:])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestResetPassword(t *testing.T) {
+	f := newFixture(t)
+	av, sink := newAccountVerification(f)
+	user, _ := f.register("forgetful")
+
+	token := requestReset(t, f, av, sink, user.Email)
+	err := av.ResetPassword(f.ctx, token, "short")
+	wantCode(t, err, domain.CodeInvalidArgument)
+	if err = av.ResetPassword(f.ctx, token, newPassword); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, err = f.users.Login(f.ctx, user.Username, newPassword); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+
+	err = av.ResetPassword(f.ctx, token, "another password 3")
+	wantIs(t, err, repositories.ErrTokenUsed)
+
+	// Unknown addresses get no mail but the same answer.
+	if err = av.RequestPasswordReset(f.ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset for an unknown address: %v", err)
+	}
+	if len(sink.Sent()) != 1 {
+		t.Errorf("sent %d mails, want 1", len(sink.Sent()))
+	}
+}
+
+func TestResetTokenInvalidatedByChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *fixture, av *AccountVerification, sink *mail.MemorySink, user domain.User) error
+	}{
+		{"another reset", func(f *fixture, av *AccountVerification, sink *mail.MemorySink, user domain.User) error {
+			return av.ResetPassword(f.ctx, requestReset(f.t, f, av, sink, user.Email), newPassword)
+		}},
+		{"password change", func(f *fixture, _ *AccountVerification, _ *mail.MemorySink, user domain.User) error {
+			return f.userService.ChangePassword(f.ctx, user.ID, testPassword, newPassword)
+		}},
+		{"email change", func(f *fixture, _ *AccountVerification, _ *mail.MemorySink, user domain.User) error {
+			email := "moved@example.com"
+			_, err := f.userService.UpdateProfile(f.ctx, user.ID, domain.ProfileUpdate{Email: &email})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			av, sink := newAccountVerification(f)
+			user, _ := f.register("forgetful")
+
+			token := requestReset(t, f, av, sink, user.Email)
+			if err := tt.change(f, av, sink, user); err != nil {
+				t.Fatalf("change: %v", err)
+			}
+			err := av.ResetPassword(f.ctx, token, "stolen password 4")
+			wantIs(t, err, repositories.ErrInvalidToken)
+			if _, err = f.users.Login(f.ctx, user.Username, "stolen password 4"); err == nil {
+				t.Error("a stale token reset the password")
+			}
+		})
+	}
+}
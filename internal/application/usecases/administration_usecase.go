@@ -0,0 +1,22 @@
+package usecases
+
+import (
+	"chat-app/internal/core/services"
+	"context"
+)
+
+// Administration holds operator actions. They do not check the caller, so
+// it must only be wired into internal tools and never into a transport that
+// end users can reach.
+type Administration struct {
+	LoginGuard *services.LoginGuardService
+}
+
+func NewAdministration(loginGuard *services.LoginGuardService) *Administration {
+	return &Administration{LoginGuard: loginGuard}
+}
+
+// UnlockAccount lifts a lockout on the username.
+func (a *Administration) UnlockAccount(ctx context.Context, username string) error {
+	return a.LoginGuard.Unlock(ctx, username)
+}
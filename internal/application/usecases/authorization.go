@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/services"
+	"context"
+)
+
+// authorize loads the caller's session and the chat and checks the
+// permission against domain.Can. Every chat and messaging action goes
+// through here; an empty permission only checks membership.
+func authorize(ctx context.Context, chatService *services.ChatService, sessionService *services.SessionService, chatID, sessionID domain.ID, permission domain.Permission) (domain.Chat, domain.Session, error) {
+	session, err := sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.Chat{}, domain.Session{}, err
+	}
+	chat, err := chatService.FindChat(ctx, chatID)
+	if err != nil {
+		return domain.Chat{}, domain.Session{}, err
+	}
+
+	if !sessionHasChat(session, chatID) || !chat.IsMember(session.UserID) {
+		return domain.Chat{}, domain.Session{}, domain.NotFound("user is not in this chat or chat doesn't exist: %v", chatID)
+	}
+	if permission != "" && !domain.Can(chat, session.UserID, permission) {
+		return domain.Chat{}, domain.Session{}, domain.Forbidden("you don't have the %s permission in this chat", permission)
+	}
+
+	return chat, session, nil
+}
+
+func (cm *ChatManagement) authorize(ctx context.Context, chatID, sessionID domain.ID, permission domain.Permission) (domain.Chat, domain.Session, error) {
+	return authorize(ctx, cm.ChatService, cm.SessionService, chatID, sessionID, permission)
+}
+
+func (m *Messaging) authorize(ctx context.Context, chatID, sessionID domain.ID, permission domain.Permission) (domain.Chat, domain.Session, error) {
+	return authorize(ctx, m.ChatService, m.SessionService, chatID, sessionID, permission)
+}
+
+// SetRolePermissions replaces the permissions granted to a role in the chat.
+func (cm *ChatManagement) SetRolePermissions(ctx context.Context, chatID, sessionID domain.ID, role string, permissions domain.Permissions) error {
+	chat, _, err := cm.authorize(ctx, chatID, sessionID, domain.PermManagePermissions)
+	if err != nil {
+		return err
+	}
+	if role != domain.Admin && role != domain.Normal {
+		return domain.InvalidArgument("permissions can only be set for the %s and %s roles", domain.Admin, domain.Normal)
+	}
+
+	updated := chat.Permissions
+	updated.Roles = make(map[string]domain.Permissions, len(chat.Permissions.Roles)+1)
+	for r, granted := range chat.Permissions.Roles {
+		updated.Roles[r] = granted
+	}
+	updated.Roles[role] = permissions
+
+	return cm.ChatService.UpdatePermissions(ctx, chatID, updated)
+}
+
+// SetMemberPermissions replaces a member's overrides; nil clears them so the
+// member falls back to their role.
+func (cm *ChatManagement) SetMemberPermissions(ctx context.Context, chatID, sessionID, userID domain.ID, overrides domain.Permissions) error {
+	chat, _, err := cm.authorize(ctx, chatID, sessionID, domain.PermManagePermissions)
+	if err != nil {
+		return err
+	}
+	if !chat.IsMember(userID) {
+		return domain.InvalidArgument("user %s is not a member of this chat", userID)
+	}
+
+	updated := chat.Permissions
+	updated.Overrides = make(map[domain.ID]domain.Permissions, len(chat.Permissions.Overrides)+1)
+	for id, granted := range chat.Permissions.Overrides {
+		updated.Overrides[id] = granted
+	}
+	if overrides == nil {
+		delete(updated.Overrides, userID)
+	} else {
+		updated.Overrides[userID] = overrides
+	}
+
+	return cm.ChatService.UpdatePermissions(ctx, chatID, updated)
+}
@@ -2,40 +2,64 @@ package usecases
 
 import (
 	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
 	"chat-app/internal/core/services"
+	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type ChatManagement struct {
-	ChatService    *services.ChatService
-	SessionService *services.SessionService
+	ChatService       *services.ChatService
+	SessionService    *services.SessionService
+	UserService       *services.UserService
+	InviteService     *services.InviteService
+	AuditService      *services.AuditService
+	ModerationService *services.ModerationService
+	RateLimiter       *services.RateLimiter
 }
 
-func NewChatManagement(chatService *services.ChatService, sessionService *services.SessionService) *ChatManagement {
+func NewChatManagement(chatService *services.ChatService, sessionService *services.SessionService, userService *services.UserService, inviteService *services.InviteService, auditService *services.AuditService, moderationService *services.ModerationService, rateLimiter *services.RateLimiter) *ChatManagement {
 	return &ChatManagement{
-		ChatService:    chatService,
-		SessionService: sessionService,
+		ChatService:       chatService,
+		SessionService:    sessionService,
+		UserService:       userService,
+		InviteService:     inviteService,
+		AuditService:      auditService,
+		ModerationService: moderationService,
+		RateLimiter:       rateLimiter,
 	}
 }
 
-func (cm *ChatManagement) CreateChat(chat domain.Chat, sessionID domain.ID) error {
-	session, err := cm.SessionService.GetSession(sessionID)
+func (cm *ChatManagement) CreateChat(ctx context.Context, chat domain.Chat, sessionID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 
-	_, exist := session.ChatNameAndID[chat.Name]
-	if exist {
-		return fmt.Errorf("chat name %s already exists", chat.Name)
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("private chats are created with CreatePrivateChat")
+	}
+	if err = services.ValidateChat(chat); err != nil {
+		return err
+	}
+	if err = cm.UserService.CheckEmailVerified(ctx, session.UserID); err != nil {
+		return err
+	}
+	if err = allowUser(ctx, cm.RateLimiter, cm.UserService, domain.OpCreateChat, session.UserID); err != nil {
+		return err
 	}
 
-	chatID, err := cm.ChatService.CreateChat(chat)
+	chatID, err := cm.ChatService.CreateChat(ctx, chat)
 
 	if err != nil {
 		return err
 	}
 
-	err = cm.SessionService.AddChatToSession(sessionID, chatID, "owner")
+	err = cm.SessionService.AddChatToSession(ctx, sessionID, chatID, chat.Name, domain.Owner)
 	if err != nil {
 		return err
 	}
@@ -43,145 +67,415 @@ func (cm *ChatManagement) CreateChat(chat domain.Chat, sessionID domain.ID) erro
 	return nil
 }
 
-func (cm *ChatManagement) FindChat(chatID, sessionID domain.ID) (domain.Chat, error) {
-	_, err := cm.chatAuthorization(domain.ID(chatID), sessionID)
+// CreatePrivateChat opens the one-to-one chat between the caller and another
+// user, returning the existing chat if the pair already has one. The chat
+// name identifies the pair, so the repository rejects a second chat even
+// when two calls race.
+func (cm *ChatManagement) CreatePrivateChat(ctx context.Context, otherUserID, sessionID domain.ID) (domain.ID, error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
 	if err != nil {
-		return domain.Chat{}, err
+		return "", err
+	}
+	if otherUserID == session.UserID {
+		return "", domain.InvalidArgument("cannot start a private chat with yourself")
 	}
 
-	chat, err := cm.ChatService.FindChat(chatID)
+	user, err := cm.UserService.GetUserInfo(ctx, session.UserID)
 	if err != nil {
-		return domain.Chat{}, err
+		return "", err
 	}
-	return chat, nil
+	otherUser, err := cm.UserService.GetUserInfo(ctx, otherUserID)
+	if err != nil {
+		return "", err
+	}
+	if user.HasBlocked(otherUserID) || otherUser.HasBlocked(session.UserID) {
+		return "", domain.Wrap(repositories.ErrUserBlocked, "cannot start a private chat")
+	}
+
+	chat, err := cm.ChatService.FindPrivateChat(ctx, session.UserID, otherUserID)
+	if err == nil {
+		return chat.ID, nil
+	}
+	if !errors.Is(err, repositories.ErrChatNotFound) {
+		return "", err
+	}
+	if err = cm.UserService.CheckEmailVerified(ctx, session.UserID); err != nil {
+		return "", err
+	}
+	if err = cm.RateLimiter.AllowUser(ctx, domain.OpCreateChat, user.ID, user.Tier); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	chat = domain.Chat{
+		ID:          domain.ID(uuid.New().String()),
+		Name:        privateChatName(session.UserID, otherUserID),
+		Owner:       session.UserID,
+		Members:     []domain.ID{session.UserID, otherUserID},
+		CreatedTime: &now,
+		ChatType:    domain.Private,
+	}
+	chatID, err := cm.ChatService.CreateChat(ctx, chat)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrDuplicateChat) {
+			return "", err
+		}
+		// Another request created the chat in the meantime.
+		existing, err := cm.ChatService.FindPrivateChat(ctx, session.UserID, otherUserID)
+		if err != nil {
+			return "", err
+		}
+		return existing.ID, nil
+	}
+
+	err = cm.SessionService.AddChatToSession(ctx, sessionID, chatID, otherUser.DisplayName(), domain.Normal)
+	if err != nil {
+		return "", err
+	}
+
+	otherSession, err := cm.SessionService.GetSessionByUserID(ctx, otherUserID)
+	if err == nil {
+		err = cm.SessionService.AddChatToSession(ctx, otherSession.SessionID, chatID, user.DisplayName(), domain.Normal)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return chatID, nil
+}
+
+// JoinChannel subscribes the caller to a public channel.
+func (cm *ChatManagement) JoinChannel(ctx context.Context, chatID, sessionID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	chat, err := cm.ChatService.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.ChatType != domain.Channel || !chat.Public {
+		return domain.Forbidden("only public channels can be joined without an invitation")
+	}
+	if chat.IsMember(session.UserID) {
+		return domain.Conflict("user is already subscribed to %s", chat.Name)
+	}
+
+	return cm.addMembers(ctx, chat, []domain.ID{session.UserID})
 }
 
-func (cm *ChatManagement) UpdateChatName(currentChatName, nextChatName string, sessionID domain.ID) error {
-	session, err := cm.SessionService.GetSession(sessionID)
+// LeaveChannel unsubscribes the caller from a channel.
+func (cm *ChatManagement) LeaveChannel(ctx context.Context, chatID, sessionID domain.ID) error {
+	chat, err := cm.ChatService.FindChat(ctx, chatID)
 	if err != nil {
 		return err
 	}
+	if chat.ChatType != domain.Channel {
+		return domain.InvalidArgument("chat %s is not a channel", chat.Name)
+	}
+
+	return cm.LeaveChat(ctx, chatID, sessionID)
+}
 
-	chatID, exist := session.ChatNameAndID[currentChatName]
-	if !exist {
-		return fmt.Errorf("wrong chat name")
+func (cm *ChatManagement) GetSubscriberCount(ctx context.Context, chatID, sessionID domain.ID) (int, error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	chat, err := cm.ChatService.FindChat(ctx, chatID)
+	if err != nil {
+		return 0, err
 	}
+	if chat.ChatType != domain.Channel {
+		return 0, domain.InvalidArgument("chat %s is not a channel", chat.Name)
+	}
+	if !chat.Public && !chat.IsMember(session.UserID) {
+		return 0, domain.Conflict("user is not subscribed to %s", chat.Name)
+	}
+
+	return cm.ChatService.SubscriberCount(ctx, chatID)
+}
 
-	chat, err := cm.ChatService.FindChat(domain.ID(chatID))
+func (cm *ChatManagement) FindChat(ctx context.Context, chatID, sessionID domain.ID) (domain.Chat, error) {
+	chat, _, err := cm.authorize(ctx, chatID, sessionID, "")
+	if err != nil {
+		return domain.Chat{}, err
+	}
+	return chat, nil
+}
+
+func (cm *ChatManagement) UpdateChatName(ctx context.Context, currentChatName, nextChatName string, sessionID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 
-	userRole, err := cm.chatAuthorization(domain.ID(chatID), sessionID)
+	chatID, err := sessionChatByName(session, currentChatName)
 	if err != nil {
 		return err
 	}
 
-	if userRole != domain.Owner {
-		return fmt.Errorf("only the owner of this chat can change the name of %s", currentChatName)
+	_, err = cm.UpdateChat(ctx, chatID, sessionID, domain.ChatUpdate{Name: &nextChatName})
+	return err
+}
+
+// UpdateChat changes the chat's profile and settings. Each field is checked
+// against its own permission: the name needs rename_chat, description,
+// topic and avatar need change_info, and settings need manage_settings.
+func (cm *ChatManagement) UpdateChat(ctx context.Context, chatID, sessionID domain.ID, update domain.ChatUpdate) (domain.Chat, error) {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, "")
+	if err != nil {
+		return domain.Chat{}, err
 	}
 
-	chat.Name = nextChatName
+	for _, permission := range requiredPermissions(update) {
+		if !domain.Can(chat, session.UserID, permission) {
+			return domain.Chat{}, domain.Forbidden("you don't have the %s permission in this chat", permission)
+		}
+	}
+
+	updated, err := cm.ChatService.UpdateChat(ctx, chatID, update)
+	if err != nil {
+		return domain.Chat{}, err
+	}
 
-	err = cm.ChatService.UpdateChatName(chat)
+	if updated.Name != chat.Name {
+		if err = cm.renameInSessions(ctx, updated); err != nil {
+			return domain.Chat{}, err
+		}
+	}
+	return updated, nil
+}
+
+func requiredPermissions(update domain.ChatUpdate) []domain.Permission {
+	var permissions []domain.Permission
+	if update.Name != nil {
+		permissions = append(permissions, domain.PermRenameChat)
+	}
+	if update.Description != nil || update.Topic != nil || update.Avatar != nil || update.RemoveAvatar {
+		permissions = append(permissions, domain.PermChangeInfo)
+	}
+	if update.History != nil || update.HistoryLastN != nil || update.JoinApproval != nil || update.SlowMode != nil {
+		permissions = append(permissions, domain.PermManageSettings)
+	}
+	if update.MembersCanAdd != nil {
+		permissions = append(permissions, domain.PermManagePermissions)
+	}
+	return permissions
+}
+
+// renameInSessions re-lists the chat under its new name in every member's
+// session.
+func (cm *ChatManagement) renameInSessions(ctx context.Context, chat domain.Chat) error {
+	for _, userID := range chatParticipants(chat) {
+		session, err := cm.SessionService.GetSessionByUserID(ctx, userID)
+		if err != nil {
+			continue
+		}
+		if err = cm.SessionService.RemoveChatFromSession(ctx, session.SessionID, chat.ID); err != nil {
+			return err
+		}
+		if err = cm.SessionService.AddChatToSession(ctx, session.SessionID, chat.ID, chat.Name, chat.RoleOf(userID)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (cm *ChatManagement) DeleteChat(chatID, sessionID domain.ID) error {
-	userRole, err := cm.chatAuthorization(chatID, sessionID)
+// DeleteChat soft-deletes the chat and hides it from every member. The
+// owner can restore it with RestoreChat during the grace period.
+func (cm *ChatManagement) DeleteChat(ctx context.Context, chatID, sessionID domain.ID) error {
+	chat, _, err := cm.authorize(ctx, chatID, sessionID, domain.PermDeleteChat)
 	if err != nil {
 		return err
 	}
-	if userRole != domain.Owner {
-		return fmt.Errorf("you are not authorized to delete this chat")
+	err = cm.ChatService.DeleteChat(ctx, chatID)
+	if err != nil {
+		return err
 	}
-	err = cm.ChatService.DeleteChat(chatID)
+
+	err = cm.SessionService.RemoveChatFromSession(ctx, sessionID, chatID)
 	if err != nil {
 		return err
 	}
 
-	err = cm.SessionService.RemoveChatFromSession(sessionID, chatID)
+	for _, userID := range chatParticipants(chat) {
+		session, err := cm.SessionService.GetSessionByUserID(ctx, userID)
+		if err != nil || session.SessionID == sessionID {
+			continue
+		}
+		if err = cm.SessionService.RemoveChatFromSession(ctx, session.SessionID, chatID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cm *ChatManagement) RestoreChat(ctx context.Context, chatID, sessionID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	chat, err := cm.ChatService.FindDeletedChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if !domain.Can(chat, session.UserID, domain.PermDeleteChat) {
+		return domain.Forbidden("only the owner can restore this chat")
+	}
+
+	chat, err = cm.ChatService.RestoreChat(ctx, chatID)
 	if err != nil {
 		return err
 	}
 
+	for _, userID := range chatParticipants(chat) {
+		userSession, err := cm.SessionService.GetSessionByUserID(ctx, userID)
+		if err != nil {
+			continue
+		}
+		err = cm.SessionService.AddChatToSession(ctx, userSession.SessionID, chatID, chat.Name, chat.RoleOf(userID))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (cm *ChatManagement) GetMessages(chatName string, sessionID domain.ID) ([]domain.Message, error) {
-	session, err := cm.SessionService.GetSession(sessionID)
+func (cm *ChatManagement) GetMessages(ctx context.Context, chatName string, sessionID domain.ID) ([]domain.Message, error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, err
 
 	}
-	chatID, exist := session.ChatNameAndID[chatName]
-	if !exist {
-		return nil, fmt.Errorf("user is not in this chat or chat doesn't exist: %v", chatName)
+	chatID, err := sessionChatByName(session, chatName)
+	if err != nil {
+		return nil, err
 	}
 
-	messages, err := cm.ChatService.GetMessages(domain.ID(chatID))
+	chat, _, err := cm.authorize(ctx, chatID, sessionID, "")
 	if err != nil {
 		return nil, err
 	}
-	return messages, nil
+
+	messages, err := cm.ChatService.GetVisibleMessages(ctx, chat, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := cm.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return withoutBlockedSenders(messages, user), nil
 
 }
 
-func (cm *ChatManagement) AddUser(chatID, sessionID domain.ID, userIDs []domain.ID) error {
-	userRole, err := cm.chatAuthorization(chatID, sessionID)
+func (cm *ChatManagement) AddUser(ctx context.Context, chatID, sessionID domain.ID, userIDs []domain.ID) error {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, domain.PermAddMembers)
 	if err != nil {
 		return err
 	}
-	if userRole != domain.Admin && userRole != domain.Owner {
-		return fmt.Errorf("you are not authorized to remove from this chat")
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		user, err := cm.UserService.GetUserInfo(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.HasBlocked(session.UserID) {
+			return domain.Wrap(repositories.ErrUserBlocked, "cannot add %s", user.Username)
+		}
+	}
+
+	return cm.addMembers(ctx, chat, userIDs)
+}
+
+// addMembers adds the users to the chat and to their sessions. Banned
+// users are refused whichever way they try to get in.
+func (cm *ChatManagement) addMembers(ctx context.Context, chat domain.Chat, userIDs []domain.ID) error {
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := cm.ModerationService.CheckCanJoin(ctx, chat.ID, userID); err != nil {
+			return err
+		}
 	}
 
-	err = cm.ChatService.AddUser(chatID, userIDs)
+	err := cm.ChatService.AddUser(ctx, chat.ID, userIDs)
 	if err != nil {
 		return err
 	}
 
 	for _, userID := range userIDs {
-		session, err := cm.SessionService.GetSessionByUserID(userID)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		session, err := cm.SessionService.GetSessionByUserID(ctx, userID)
 		if err != nil {
 			return err
 		}
-		err = cm.SessionService.AddChatToSession(session.SessionID, chatID, domain.Normal)
+		err = cm.SessionService.AddChatToSession(ctx, session.SessionID, chat.ID, chat.Name, domain.Normal)
 		if err != nil {
 			return err
 		}
-
 	}
 
 	return nil
-
 }
 
-func (cm *ChatManagement) RemoveUser(chatID, sessionID domain.ID, userIDs []domain.ID) error {
-	userRole, err := cm.chatAuthorization(chatID, sessionID)
+func (cm *ChatManagement) RemoveUser(ctx context.Context, chatID, sessionID domain.ID, userIDs []domain.ID) error {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, domain.PermRemoveMembers)
 	if err != nil {
 		return err
 	}
 
-	if userRole != domain.Admin && userRole != domain.Owner {
-		return fmt.Errorf("you are not authorized to remove from this chat")
+	for _, userID := range userIDs {
+		if err = canManage(chat.RoleOf(session.UserID), chat.RoleOf(userID)); err != nil {
+			return err
+		}
 	}
 
-	err = cm.ChatService.RemoveUser(chatID, userIDs)
+	return cm.removeMembers(ctx, chat, userIDs)
+}
+
+// removeMembers removes the users from the chat and from their sessions.
+func (cm *ChatManagement) removeMembers(ctx context.Context, chat domain.Chat, userIDs []domain.ID) error {
+	err := cm.ChatService.RemoveUser(ctx, chat.ID, userIDs)
 	if err != nil {
 		return err
 	}
-	return nil
 
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Users without an active session pick up the change at next login.
+		session, err := cm.SessionService.GetSessionByUserID(ctx, userID)
+		if err != nil {
+			continue
+		}
+		err = cm.SessionService.RemoveChatFromSession(ctx, session.SessionID, chat.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (cm *ChatManagement) GetMembers(chatID, sessionID domain.ID) ([]domain.ID, error) {
-	_, err := cm.chatAuthorization(chatID, sessionID)
+func (cm *ChatManagement) GetMembers(ctx context.Context, chatID, sessionID domain.ID) ([]domain.ID, error) {
+	_, _, err := cm.authorize(ctx, chatID, sessionID, "")
 	if err != nil {
 		return nil, err
 	}
 
-	chatMembers, err := cm.ChatService.GetMembers(chatID)
+	chatMembers, err := cm.ChatService.GetMembers(ctx, chatID)
 	if err != nil {
 		return nil, err
 	}
@@ -189,59 +483,167 @@ func (cm *ChatManagement) GetMembers(chatID, sessionID domain.ID) ([]domain.ID,
 
 }
 
-func (cm *ChatManagement) SetAdmin(chatID, sessionID domain.ID, userIDs []domain.ID) error {
-	role, err := cm.chatAuthorization(chatID, sessionID)
-	if err != nil {
-		return err
+func (cm *ChatManagement) GetAuditLog(ctx context.Context, chatID, sessionID domain.ID) ([]domain.Event, error) {
+	if _, _, err := cm.authorize(ctx, chatID, sessionID, domain.PermViewAuditLog); err != nil {
+		return nil, err
 	}
+	return cm.AuditService.ListRecords(ctx, chatID)
+}
+
+// SetSlowMode sets the minimum interval between a member's messages; zero
+// turns slow mode off.
+func (cm *ChatManagement) SetSlowMode(ctx context.Context, chatID, sessionID domain.ID, interval time.Duration) error {
+	_, err := cm.UpdateChat(ctx, chatID, sessionID, domain.ChatUpdate{SlowMode: &interval})
+	return err
+}
+
+func (cm *ChatManagement) SetAdmin(ctx context.Context, chatID, sessionID domain.ID, userIDs []domain.ID) error {
+	return cm.changeAdminRole(ctx, chatID, sessionID, userIDs, domain.Admin)
+}
+
+func (cm *ChatManagement) RemoveAdmin(ctx context.Context, chatID, sessionID domain.ID, userIDs []domain.ID) error {
+	return cm.changeAdminRole(ctx, chatID, sessionID, userIDs, domain.Normal)
+}
 
-	if role != domain.Admin && role != domain.Owner {
-		return fmt.Errorf("you are not authorized to set admin in this chat")
+// changeAdminRole promotes members to Admin or demotes admins to Normal.
+// Only the owner may do either.
+func (cm *ChatManagement) changeAdminRole(ctx context.Context, chatID, sessionID domain.ID, userIDs []domain.ID, newRole string) error {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, domain.PermManageAdmins)
+	if err != nil {
+		return err
 	}
 
 	for _, userID := range userIDs {
-		err = cm.ChatService.SetAdmin(userID, chatID)
+		oldRole := chat.RoleOf(userID)
+		switch {
+		case oldRole == "":
+			return domain.InvalidArgument("user %s is not a member of this chat", userID)
+		case oldRole == domain.Owner:
+			return domain.Forbidden("the owner's role cannot be changed")
+		case oldRole == newRole:
+			continue
+		}
+
+		if newRole == domain.Admin {
+			err = cm.ChatService.SetAdmin(ctx, userID, chatID)
+		} else {
+			err = cm.ChatService.RemoveAdmin(ctx, userID, chatID)
+		}
 		if err != nil {
 			return err
 		}
+
+		if err = cm.recordRoleChange(ctx, chatID, session.UserID, userID, oldRole, newRole); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (cm *ChatManagement) chatAuthorization(chatID, sessionID domain.ID) (string, error) {
-	session, err := cm.SessionService.GetSession(sessionID)
-	if err != nil {
-		return "", err
+var roleRank = map[string]int{
+	domain.Normal: 1,
+	domain.Admin:  2,
+	domain.Owner:  3,
+}
 
+// canManage enforces the role hierarchy on top of permissions: members can
+// only be managed by someone ranked above them, so the owner manages
+// everyone else and admins manage only normal members. Normal members who
+// were granted a management permission use it on other normal members.
+func canManage(actorRole, targetRole string) error {
+	if targetRole == "" {
+		return domain.Forbidden("user is not a member of this chat")
 	}
-	chat, err := cm.ChatService.FindChat(chatID)
-	if err != nil {
-		return "", err
+	if actorRole == domain.Normal && targetRole == domain.Normal {
+		return nil
 	}
+	if roleRank[actorRole] <= roleRank[targetRole] {
+		return domain.Forbidden("a chat %s cannot manage a chat %s", actorRole, targetRole)
+	}
+	return nil
+}
 
-	userChatID, exist := session.ChatNameAndID[chat.Name]
-	if !exist {
-		return "", fmt.Errorf("user is not in this chat or chat doesn't exist: %v", chat.Name)
+func sessionHasChat(session domain.Session, chatID domain.ID) bool {
+	_, ok := session.ChatIDAndName[string(chatID)]
+	return ok
+}
+
+// sessionChatByName finds the chat the session lists under name. Names are
+// not unique, so ambiguous names are rejected and the caller has to use the
+// chat ID instead.
+func sessionChatByName(session domain.Session, name string) (domain.ID, error) {
+	var found []domain.ID
+	for chatID, chatName := range session.ChatIDAndName {
+		if chatName == name {
+			found = append(found, domain.ID(chatID))
+		}
 	}
+	switch len(found) {
+	case 0:
+		return "", domain.NotFound("user is not in this chat or chat doesn't exist: %v", name)
+	case 1:
+		return found[0], nil
+	}
+	return "", domain.InvalidArgument("more than one chat is named %v, use the chat ID", name)
+}
 
-	if domain.ID(userChatID) != chatID {
-		return "", fmt.Errorf("chatID is not valid: %v", chatID)
+// privateChatName identifies the pair of a private chat regardless of who
+// started it.
+func privateChatName(userID, otherUserID domain.ID) string {
+	if otherUserID < userID {
+		userID, otherUserID = otherUserID, userID
 	}
+	return fmt.Sprintf("%s:%s", userID, otherUserID)
+}
 
-	for _, admin := range chat.Admins {
-		if admin == session.UserID {
-			role := domain.Admin
-			return role, nil
+// chatNameFor returns the name a chat is listed under for the given user:
+// the other participant's name for private chats, the chat name otherwise.
+func chatNameFor(ctx context.Context, userService *services.UserService, chat domain.Chat, userID domain.ID) (string, error) {
+	if chat.ChatType != domain.Private {
+		return chat.Name, nil
+	}
+	for _, member := range chat.Members {
+		if member == userID {
+			continue
 		}
+		other, err := userService.GetUserInfo(ctx, member)
+		if err != nil {
+			return "", err
+		}
+		return other.DisplayName(), nil
 	}
+	return "", domain.NewError(domain.CodeInternal, "private chat %v has no other participant", chat.ID)
+}
 
-	if chat.Owner == session.UserID {
-		role := domain.Owner
-		return role, nil
+// chatParticipants returns the owner, admins and members of the chat
+// without duplicates.
+func chatParticipants(chat domain.Chat) []domain.ID {
+	all := append([]domain.ID{chat.Owner}, chat.Admins...)
+	all = append(all, chat.Members...)
+
+	seen := make(map[domain.ID]bool, len(all))
+	var participants []domain.ID
+	for _, userID := range all {
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		participants = append(participants, userID)
 	}
+	return participants
+}
 
-	role := domain.Normal
-	return role, nil
-
+// withoutBlockedSenders hides messages from users the reader has blocked.
+func withoutBlockedSenders(messages []domain.Message, reader domain.User) []domain.Message {
+	if len(reader.Blocked) == 0 {
+		return messages
+	}
+	var visible []domain.Message
+	for _, message := range messages {
+		if !reader.HasBlocked(message.SenderID) {
+			visible = append(visible, message)
+		}
+	}
+	return visible
 }
@@ -0,0 +1,501 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreatePrivateChat(t *testing.T) {
+	f := newFixture(t)
+	alice, aliceSession := f.register("alice")
+	bob, bobSession := f.register("bob")
+
+	chatID, err := f.chatManagement.CreatePrivateChat(f.ctx, bob.ID, aliceSession)
+	if err != nil {
+		t.Fatalf("CreatePrivateChat: %v", err)
+	}
+	again, err := f.chatManagement.CreatePrivateChat(f.ctx, alice.ID, bobSession)
+	if err != nil {
+		t.Fatalf("CreatePrivateChat from the other side: %v", err)
+	}
+	if again != chatID {
+		t.Errorf("second CreatePrivateChat = %v, want the existing chat %v", again, chatID)
+	}
+
+	if got := f.session(aliceSession).ChatIDAndName[string(chatID)]; got != bob.DisplayName() {
+		t.Errorf("alice lists the chat as %q, want %q", got, bob.DisplayName())
+	}
+	if got := f.session(bobSession).ChatIDAndName[string(chatID)]; got != alice.DisplayName() {
+		t.Errorf("bob lists the chat as %q, want %q", got, alice.DisplayName())
+	}
+
+	_, err = f.chatManagement.CreatePrivateChat(f.ctx, alice.ID, aliceSession)
+	wantCode(t, err, domain.CodeInvalidArgument)
+}
+
+func TestCreatePrivateChatBlocked(t *testing.T) {
+	f := newFixture(t)
+	alice, _ := f.register("alice")
+	bob, bobSession := f.register("bob")
+	if err := f.userService.BlockUser(f.ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := f.chatManagement.CreatePrivateChat(f.ctx, alice.ID, bobSession)
+	wantIs(t, err, repositories.ErrUserBlocked)
+}
+
+func TestDeletePrivateChat(t *testing.T) {
+	f := newFixture(t)
+	alice, aliceSession := f.register("alice")
+	bob, bobSession := f.register("bob")
+
+	chatID, err := f.chatManagement.CreatePrivateChat(f.ctx, bob.ID, aliceSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bob did not start the chat, but either participant may delete it.
+	if err = f.chatManagement.DeleteChat(f.ctx, chatID, bobSession); err != nil {
+		t.Fatalf("DeleteChat: %v", err)
+	}
+	if _, ok := f.session(aliceSession).ChatIDAndName[string(chatID)]; ok {
+		t.Error("deleted chat is still listed in alice's session")
+	}
+	_, err = f.chatService.FindPrivateChat(f.ctx, alice.ID, bob.ID)
+	wantIs(t, err, repositories.ErrChatNotFound)
+
+	newChatID, err := f.chatManagement.CreatePrivateChat(f.ctx, alice.ID, bobSession)
+	if err != nil {
+		t.Fatalf("CreatePrivateChat after delete: %v", err)
+	}
+	if newChatID == chatID {
+		t.Fatal("CreatePrivateChat returned the deleted chat")
+	}
+
+	err = f.chatManagement.RestoreChat(f.ctx, chatID, aliceSession)
+	wantCode(t, err, domain.CodeConflict)
+}
+
+func TestPrivateChatCannotBeChanged(t *testing.T) {
+	f := newFixture(t)
+	_, aliceSession := f.register("alice")
+	bob, _ := f.register("bob")
+	carol, _ := f.register("carol")
+
+	chatID, err := f.chatManagement.CreatePrivateChat(f.ctx, bob.ID, aliceSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "renamed"
+	_, err = f.chatManagement.UpdateChat(f.ctx, chatID, aliceSession, domain.ChatUpdate{Name: &name})
+	wantCode(t, err, domain.CodeForbidden)
+	err = f.chatManagement.AddUser(f.ctx, chatID, aliceSession, []domain.ID{carol.ID})
+	wantCode(t, err, domain.CodeForbidden)
+	err = f.chatManagement.LeaveChat(f.ctx, chatID, aliceSession)
+	wantCode(t, err, domain.CodeInvalidArgument)
+}
+
+func TestChannelPosting(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	admin, adminSession := f.register("admin")
+	subscriber, subscriberSession := f.register("subscriber")
+	chatID := f.newChat(domain.Channel, "news", ownerSession, admin.ID, subscriber.ID)
+	if err := f.chatManagement.SetAdmin(f.ctx, chatID, ownerSession, []domain.ID{admin.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overrides cannot let subscribers post either.
+	grant := domain.Permissions{domain.PermSendMessages: true, domain.PermSendMedia: true}
+	if err := f.chatManagement.SetMemberPermissions(f.ctx, chatID, ownerSession, subscriber.ID, grant); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID domain.ID
+		wantCode  domain.ErrorCode
+	}{
+		{"owner", ownerSession, ""},
+		{"admin", adminSession, ""},
+		{"subscriber", subscriberSession, domain.CodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.messaging.SendMessage(f.ctx, chatID, tt.sessionID, "post")
+			wantCode(t, err, tt.wantCode)
+			err = f.messaging.SendMedia(f.ctx, chatID, tt.sessionID, "", []domain.Attachment{{ID: "a", Name: "cat.png", ContentType: "image/png", Size: 1}})
+			wantCode(t, err, tt.wantCode)
+		})
+	}
+}
+
+func TestChannelSubscription(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	_, readerSession := f.register("reader")
+	public := f.createChat(domain.Chat{Name: "news", ChatType: domain.Channel, Public: true}, ownerSession)
+	closed := f.newChat(domain.Channel, "closed", ownerSession)
+	group := f.newChat(domain.Group, "team", ownerSession)
+
+	if err := f.chatManagement.JoinChannel(f.ctx, public, readerSession); err != nil {
+		t.Fatalf("JoinChannel: %v", err)
+	}
+	if _, ok := f.session(readerSession).ChatIDAndName[string(public)]; !ok {
+		t.Error("the channel is not listed in the subscriber's session")
+	}
+	wantCode(t, f.chatManagement.JoinChannel(f.ctx, public, readerSession), domain.CodeConflict)
+	wantCode(t, f.chatManagement.JoinChannel(f.ctx, closed, readerSession), domain.CodeForbidden)
+	wantCode(t, f.chatManagement.JoinChannel(f.ctx, group, readerSession), domain.CodeForbidden)
+
+	count, err := f.chatManagement.GetSubscriberCount(f.ctx, public, readerSession)
+	if err != nil || count != 2 {
+		t.Fatalf("GetSubscriberCount = %d, %v, want 2", count, err)
+	}
+
+	if err = f.chatManagement.LeaveChannel(f.ctx, public, readerSession); err != nil {
+		t.Fatalf("LeaveChannel: %v", err)
+	}
+	if count, err = f.chatManagement.GetSubscriberCount(f.ctx, public, ownerSession); err != nil || count != 1 {
+		t.Fatalf("GetSubscriberCount after leaving = %d, %v, want 1", count, err)
+	}
+	wantCode(t, f.chatManagement.LeaveChannel(f.ctx, group, ownerSession), domain.CodeInvalidArgument)
+}
+
+func TestChannelViews(t *testing.T) {
+	f := newFixture(t)
+	owner, ownerSession := f.register("owner")
+	first, firstSession := f.register("first")
+	second, secondSession := f.register("second")
+	channel := f.newChat(domain.Channel, "news", ownerSession, first.ID, second.ID)
+	group := f.newChat(domain.Group, "team", ownerSession, first.ID)
+
+	if err := f.messaging.SendMessage(f.ctx, channel, ownerSession, "post"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.messaging.SendMessage(f.ctx, group, ownerSession, "chat"); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := f.messageService.ListUserMessages(f.ctx, owner.ID)
+	if err != nil || len(sent) != 2 {
+		t.Fatalf("ListUserMessages = %v, %v", sent, err)
+	}
+	post, chat := sent[0], sent[1]
+
+	for _, sessionID := range []domain.ID{firstSession, firstSession, secondSession} {
+		if err = f.messaging.ViewMessages(f.ctx, channel, sessionID, []domain.ID{post.ID}); err != nil {
+			t.Fatalf("ViewMessages: %v", err)
+		}
+	}
+	viewed, err := f.messageService.FindMessage(f.ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if viewed.Views != 2 {
+		t.Errorf("views = %d, want 2: one per subscriber", viewed.Views)
+	}
+
+	err = f.messaging.ViewMessages(f.ctx, channel, firstSession, []domain.ID{chat.ID})
+	wantCode(t, err, domain.CodeNotFound)
+	err = f.messaging.ViewMessages(f.ctx, group, firstSession, []domain.ID{chat.ID})
+	wantCode(t, err, domain.CodeInvalidArgument)
+}
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		actor, target string
+		wantCode      domain.ErrorCode
+	}{
+		{domain.Owner, domain.Admin, ""},
+		{domain.Owner, domain.Normal, ""},
+		{domain.Admin, domain.Normal, ""},
+		{domain.Admin, domain.Admin, domain.CodeForbidden},
+		{domain.Admin, domain.Owner, domain.CodeForbidden},
+		{domain.Normal, domain.Normal, ""},
+		{domain.Normal, domain.Admin, domain.CodeForbidden},
+		{domain.Owner, "", domain.CodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.actor+" manages "+tt.target, func(t *testing.T) {
+			wantCode(t, canManage(tt.actor, tt.target), tt.wantCode)
+		})
+	}
+}
+
+func TestChangeAdminRole(t *testing.T) {
+	f := newFixture(t)
+	owner, ownerSession := f.register("owner")
+	admin, adminSession := f.register("admin")
+	member, memberSession := f.register("member")
+	outsider, _ := f.register("outsider")
+	chatID := f.newChat(domain.Group, "team", ownerSession, admin.ID, member.ID)
+
+	if err := f.chatManagement.SetAdmin(f.ctx, chatID, ownerSession, []domain.ID{admin.ID}); err != nil {
+		t.Fatalf("SetAdmin: %v", err)
+	}
+	if role, err := f.sessionService.IsUserInChat(f.ctx, adminSession, chatID); err != nil || role != domain.Admin {
+		t.Errorf("admin's session role = %q, %v, want %q", role, err, domain.Admin)
+	}
+
+	tests := []struct {
+		name     string
+		change   func() error
+		wantCode domain.ErrorCode
+	}{
+		{"admin promotes a member", func() error {
+			return f.chatManagement.SetAdmin(f.ctx, chatID, adminSession, []domain.ID{member.ID})
+		}, domain.CodeForbidden},
+		{"admin demotes themself", func() error {
+			return f.chatManagement.RemoveAdmin(f.ctx, chatID, adminSession, []domain.ID{admin.ID})
+		}, domain.CodeForbidden},
+		{"owner demotes themself", func() error {
+			return f.chatManagement.RemoveAdmin(f.ctx, chatID, ownerSession, []domain.ID{owner.ID})
+		}, domain.CodeForbidden},
+		{"owner promotes an outsider", func() error {
+			return f.chatManagement.SetAdmin(f.ctx, chatID, ownerSession, []domain.ID{outsider.ID})
+		}, domain.CodeInvalidArgument},
+		{"admin removes the owner", func() error {
+			return f.chatManagement.RemoveUser(f.ctx, chatID, adminSession, []domain.ID{owner.ID})
+		}, domain.CodeForbidden},
+		{"member removes a member", func() error {
+			return f.chatManagement.RemoveUser(f.ctx, chatID, memberSession, []domain.ID{admin.ID})
+		}, domain.CodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantCode(t, tt.change(), tt.wantCode)
+		})
+	}
+
+	if err := f.chatManagement.RemoveAdmin(f.ctx, chatID, ownerSession, []domain.ID{admin.ID}); err != nil {
+		t.Fatalf("RemoveAdmin: %v", err)
+	}
+	if role := f.chat(chatID).RoleOf(admin.ID); role != domain.Normal {
+		t.Errorf("demoted admin's role = %q, want %q", role, domain.Normal)
+	}
+	if role, err := f.sessionService.IsUserInChat(f.ctx, adminSession, chatID); err != nil || role != domain.Normal {
+		t.Errorf("demoted admin's session role = %q, %v, want %q", role, err, domain.Normal)
+	}
+
+	var changes []string
+	for _, event := range f.audit.Published() {
+		if event.Type == domain.EventRoleChanged && event.TargetID == admin.ID && event.ActorID == owner.ID {
+			changes = append(changes, event.Details["old_role"]+" -> "+event.Details["new_role"])
+		}
+	}
+	if want := []string{"Normal -> Admin", "Admin -> Normal"}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("role changes = %v, want %v", changes, want)
+	}
+}
+
+func TestUpdateChat(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	admin, adminSession := f.register("admin")
+	member, memberSession := f.register("member")
+	chatID := f.newChat(domain.Group, "team", ownerSession, admin.ID, member.ID)
+	if err := f.chatManagement.SetAdmin(f.ctx, chatID, ownerSession, []domain.ID{admin.ID}); err != nil {
+		t.Fatalf("SetAdmin: %v", err)
+	}
+
+	name, topic, description := "crew", "launch", "the launch crew"
+	longTopic := strings.Repeat("t", 257)
+	slowMode, negative := time.Minute, -time.Second
+	history := domain.HistorySinceJoined
+	membersCanAdd := true
+	photo := &domain.Attachment{ID: "a", Name: "a.png", ContentType: "image/png", Size: 1, StorageKey: "a"}
+	document := &domain.Attachment{ID: "b", Name: "b.pdf", ContentType: "application/pdf", Size: 1, StorageKey: "b"}
+
+	tests := []struct {
+		name     string
+		session  domain.ID
+		update   domain.ChatUpdate
+		wantCode domain.ErrorCode
+	}{
+		{"member renames", memberSession, domain.ChatUpdate{Name: &name}, domain.CodeForbidden},
+		{"member sets topic", memberSession, domain.ChatUpdate{Topic: &topic}, domain.CodeForbidden},
+		{"member sets slow mode", memberSession, domain.ChatUpdate{SlowMode: &slowMode}, domain.CodeForbidden},
+		{"admin renames", adminSession, domain.ChatUpdate{Name: &name}, domain.CodeForbidden},
+		{"admin lets members add", adminSession, domain.ChatUpdate{MembersCanAdd: &membersCanAdd}, domain.CodeForbidden},
+		{"admin sets a document as avatar", adminSession, domain.ChatUpdate{Avatar: document}, domain.CodeInvalidArgument},
+		{"admin sets negative slow mode", adminSession, domain.ChatUpdate{SlowMode: &negative}, domain.CodeInvalidArgument},
+		{"admin sets a long topic", adminSession, domain.ChatUpdate{Topic: &longTopic}, domain.CodeInvalidArgument},
+		{"admin changes info and settings", adminSession, domain.ChatUpdate{
+			Description: &description, Topic: &topic, Avatar: photo, History: &history, SlowMode: &slowMode,
+		}, ""},
+		{"owner renames and lets members add", ownerSession, domain.ChatUpdate{Name: &name, MembersCanAdd: &membersCanAdd}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.chatManagement.UpdateChat(f.ctx, chatID, tt.session, tt.update)
+			wantCode(t, err, tt.wantCode)
+		})
+	}
+
+	chat := f.chat(chatID)
+	if chat.Name != name || chat.Description != description || chat.Topic != topic || !reflect.DeepEqual(chat.Avatar, photo) {
+		t.Errorf("profile = %q, %q, %q, %v; want %q, %q, %q, %v",
+			chat.Name, chat.Description, chat.Topic, chat.Avatar, name, description, topic, photo)
+	}
+	if want := (domain.ChatSettings{History: history, SlowMode: slowMode}); chat.Settings != want {
+		t.Errorf("settings = %+v, want %+v", chat.Settings, want)
+	}
+	if !domain.Can(chat, member.ID, domain.PermAddMembers) {
+		t.Error("members cannot add members after MembersCanAdd")
+	}
+	if got := f.session(memberSession).ChatIDAndName[string(chatID)]; got != name {
+		t.Errorf("member's session lists the chat as %q, want %q", got, name)
+	}
+}
+
+func TestHistoryVisibility(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	late, lateSession := f.register("late")
+	chatID := f.createChat(domain.Chat{Name: "team", ChatType: domain.Group, Settings: domain.ChatSettings{History: domain.HistoryLastN, HistoryLastN: 1}}, ownerSession)
+
+	for _, text := range []string{"note one", "note two"} {
+		if err := f.messaging.SendMessage(f.ctx, chatID, ownerSession, text); err != nil {
+			t.Fatalf("send %q: %v", text, err)
+		}
+	}
+	if err := f.chatManagement.addMembers(f.ctx, f.chat(chatID), []domain.ID{late.ID}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := f.messaging.SendMessage(f.ctx, chatID, ownerSession, "note three"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	read := func(sessionID domain.ID) (messages, found []string) {
+		t.Helper()
+		got, err := f.chatManagement.GetMessages(f.ctx, "team", sessionID)
+		if err != nil {
+			t.Fatalf("GetMessages: %v", err)
+		}
+		for _, message := range got {
+			messages = append(messages, message.Content)
+		}
+		results, err := f.messaging.SearchMessages(f.ctx, sessionID, domain.SearchQuery{Text: "note", ChatID: chatID})
+		if err != nil {
+			t.Fatalf("SearchMessages: %v", err)
+		}
+		for _, result := range results {
+			found = append(found, result.Message.Content)
+		}
+		sort.Strings(found)
+		return messages, found
+	}
+
+	tests := []struct {
+		name      string
+		history   domain.HistoryVisibility
+		sessionID domain.ID
+		want      []string
+	}{
+		{"owner reads everything", domain.HistoryLastN, ownerSession, []string{"note one", "note two", "note three"}},
+		{"late member reads the last one before joining", domain.HistoryLastN, lateSession, []string{"note two", "note three"}},
+		{"late member reads since joining", domain.HistorySinceJoined, lateSession, []string{"note three"}},
+		{"late member reads everything", domain.HistoryFull, lateSession, []string{"note one", "note two", "note three"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.chatManagement.UpdateChat(f.ctx, chatID, ownerSession, domain.ChatUpdate{History: &tt.history}); err != nil {
+				t.Fatalf("UpdateChat: %v", err)
+			}
+			messages, found := read(tt.sessionID)
+			if !reflect.DeepEqual(messages, tt.want) {
+				t.Errorf("GetMessages = %v, want %v", messages, tt.want)
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(found, want) {
+				t.Errorf("SearchMessages = %v, want %v", found, want)
+			}
+		})
+	}
+}
+
+func TestCancelledContextStopsLoops(t *testing.T) {
+	f := newFixture(t)
+	owner, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	outsider, _ := f.register("outsider")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID)
+	if err := f.messaging.SendMessage(f.ctx, chatID, ownerSession, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-services.DefaultAccountDeletionGracePeriod - time.Hour)
+	if err := f.users.SetDeletedTime(f.ctx, member.ID, &expired); err != nil {
+		t.Fatal(err)
+	}
+	maintenance := NewMaintenance(f.chatService, f.messageService, f.moderationService, f.userService, f.sessionService, f.contactService, f.chatManagement)
+
+	ctx, cancel := context.WithCancel(f.ctx)
+	cancel()
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"AddUser", func() error {
+			return f.chatManagement.AddUser(ctx, chatID, ownerSession, []domain.ID{outsider.ID})
+		}},
+		{"LeaveAllChats", func() error {
+			return f.chatManagement.LeaveAllChats(ctx, owner.ID)
+		}},
+		{"SearchMessages", func() error {
+			_, err := f.messaging.SearchMessages(ctx, memberSession, domain.SearchQuery{Text: "hello"})
+			return err
+		}},
+		{"ImportContacts", func() error {
+			_, err := f.contactManagement.ImportContacts(ctx, ownerSession, []string{"outsider@example.com"})
+			return err
+		}},
+		{"PurgeDeletedUsers", func() error {
+			return maintenance.PurgeDeletedUsers(ctx)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantIs(t, tt.run(), context.Canceled)
+		})
+	}
+
+	chat := f.chat(chatID)
+	if chat.Owner != owner.ID || !reflect.DeepEqual(chat.Members, []domain.ID{owner.ID, member.ID}) {
+		t.Errorf("chat owner %s and members %v changed after cancelled calls", chat.Owner, chat.Members)
+	}
+	f.wantContacts(owner.ID)
+}
+
+func TestRepositoryErrorsSurfaceWithTheirCodes(t *testing.T) {
+	f := newFixture(t)
+	_, session := f.register("user")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantIs   error
+		wantCode domain.ErrorCode
+	}{
+		{"unknown session", f.chatManagement.DeleteChat(f.ctx, "chat", "expired"), repositories.ErrSessionNotFound, domain.CodeUnauthenticated},
+		{"unknown chat", f.chatManagement.DeleteChat(f.ctx, "missing", session), nil, domain.CodeNotFound},
+		{"unknown user", f.chatManagement.AddUser(f.ctx, f.newChat(domain.Group, "team", session), session, []domain.ID{"nobody"}), repositories.ErrUserNotFound, domain.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantCode(t, tt.err, tt.wantCode)
+			if tt.wantIs != nil {
+				wantIs(t, tt.err, tt.wantIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"errors"
+)
+
+// LeaveChat removes the caller from the chat. If the caller owns it,
+// ownership passes to chat.Successor, or the chat is deleted when empty.
+func (cm *ChatManagement) LeaveChat(ctx context.Context, chatID, sessionID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	chat, err := cm.ChatService.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if !chat.IsMember(session.UserID) {
+		return domain.NotFound("user is not in this chat or chat doesn't exist: %v", chatID)
+	}
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("private chats cannot be left")
+	}
+
+	if err = cm.departChat(ctx, chat, session.UserID); err != nil {
+		return err
+	}
+	return cm.SessionService.RemoveChatFromSession(ctx, sessionID, chatID)
+}
+
+// LeaveAllChats removes a user from every group and channel they belong
+// to, handing over or deleting the chats they own. Private chats are kept
+// so that the other participant still has the conversation.
+func (cm *ChatManagement) LeaveAllChats(ctx context.Context, userID domain.ID) error {
+	chatIDList, err := cm.UserService.GetChatIDList(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, chatID := range chatIDList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chat, err := cm.ChatService.FindChat(ctx, domain.ID(chatID))
+		if err != nil {
+			if errors.Is(err, repositories.ErrChatNotFound) {
+				continue
+			}
+			return err
+		}
+		if chat.ChatType == domain.Private || !chat.IsMember(userID) {
+			continue
+		}
+		if err = cm.departChat(ctx, chat, userID); err != nil {
+			return domain.Wrap(err, "failed to leave chat %v", chat.ID)
+		}
+	}
+	return nil
+}
+
+// TransferOwnership hands the chat over to another member. The previous
+// owner stays in the chat as an admin.
+func (cm *ChatManagement) TransferOwnership(ctx context.Context, chatID, sessionID, newOwnerID domain.ID) error {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, domain.PermTransferOwnership)
+	if err != nil {
+		return err
+	}
+	if newOwnerID == session.UserID {
+		return domain.Conflict("user already owns this chat")
+	}
+
+	if err = cm.ChatService.SetOwner(ctx, newOwnerID, chatID); err != nil {
+		return err
+	}
+	if err = cm.ChatService.SetAdmin(ctx, session.UserID, chatID); err != nil {
+		return err
+	}
+
+	if err = cm.recordRoleChange(ctx, chatID, session.UserID, session.UserID, domain.Owner, domain.Admin); err != nil {
+		return err
+	}
+	return cm.recordRoleChange(ctx, chatID, session.UserID, newOwnerID, chat.RoleOf(newOwnerID), domain.Owner)
+}
+
+// departChat removes the user from the chat, handing ownership over first
+// if needed. The caller is responsible for the leaving user's own session.
+func (cm *ChatManagement) departChat(ctx context.Context, chat domain.Chat, userID domain.ID) error {
+	if chat.Owner != userID {
+		return cm.ChatService.RemoveUser(ctx, chat.ID, []domain.ID{userID})
+	}
+
+	successor, ok := chat.Successor()
+	if !ok {
+		return cm.ChatService.DeleteChat(ctx, chat.ID)
+	}
+
+	if err := cm.ChatService.SetOwner(ctx, successor, chat.ID); err != nil {
+		return err
+	}
+	if err := cm.ChatService.RemoveUser(ctx, chat.ID, []domain.ID{userID}); err != nil {
+		return err
+	}
+	return cm.recordRoleChange(ctx, chat.ID, userID, successor, chat.RoleOf(successor), domain.Owner)
+}
+
+// recordRoleChange syncs the target's session with the new role and writes
+// the change to the audit log.
+func (cm *ChatManagement) recordRoleChange(ctx context.Context, chatID, actorID, targetID domain.ID, oldRole, newRole string) error {
+	if err := cm.syncSessionRole(ctx, targetID, chatID, newRole); err != nil {
+		return err
+	}
+	return cm.AuditService.Record(ctx, domain.Event{
+		Type:     domain.EventRoleChanged,
+		ChatID:   chatID,
+		ActorID:  actorID,
+		TargetID: targetID,
+		Details: map[string]string{
+			"old_role": oldRole,
+			"new_role": newRole,
+		},
+	})
+}
+
+// syncSessionRole updates the chat role cached in another user's session.
+// Users without an active session are skipped; their next login rebuilds
+// the session from the chats themselves.
+func (cm *ChatManagement) syncSessionRole(ctx context.Context, userID, chatID domain.ID, role string) error {
+	session, err := cm.SessionService.GetSessionByUserID(ctx, userID)
+	if err != nil {
+		return nil
+	}
+	return cm.SessionService.UpdateChatRole(ctx, session.SessionID, chatID, role)
+}
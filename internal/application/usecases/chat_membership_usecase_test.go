@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"testing"
+)
+
+func TestLeaveChat(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID)
+
+	if err := f.chatManagement.LeaveChat(f.ctx, chatID, memberSession); err != nil {
+		t.Fatalf("LeaveChat: %v", err)
+	}
+	if f.chat(chatID).IsMember(member.ID) {
+		t.Error("member is still in the chat")
+	}
+	if _, ok := f.session(memberSession).ChatIDAndName[string(chatID)]; ok {
+		t.Error("chat is still listed in the session")
+	}
+	wantCode(t, f.chatManagement.LeaveChat(f.ctx, chatID, memberSession), domain.CodeNotFound)
+}
+
+func TestOwnerLeavingHandsChatOver(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	admin, adminSession := f.register("admin")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID, admin.ID)
+	if err := f.chatManagement.SetAdmin(f.ctx, chatID, ownerSession, []domain.ID{admin.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The admin inherits the chat although the member joined first.
+	if err := f.chatManagement.LeaveChat(f.ctx, chatID, ownerSession); err != nil {
+		t.Fatalf("owner LeaveChat: %v", err)
+	}
+	if owner := f.chat(chatID).Owner; owner != admin.ID {
+		t.Fatalf("owner = %s, want the admin %s", owner, admin.ID)
+	}
+	if role, err := f.sessionService.IsUserInChat(f.ctx, adminSession, chatID); err != nil || role != domain.Owner {
+		t.Errorf("admin's session role = %q, %v, want %q", role, err, domain.Owner)
+	}
+
+	if err := f.chatManagement.LeaveChat(f.ctx, chatID, adminSession); err != nil {
+		t.Fatal(err)
+	}
+	if owner := f.chat(chatID).Owner; owner != member.ID {
+		t.Fatalf("owner = %s, want the member %s", owner, member.ID)
+	}
+
+	// The last member leaving deletes the chat.
+	if err := f.chatManagement.LeaveChat(f.ctx, chatID, memberSession); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.chatService.FindChat(f.ctx, chatID)
+	wantIs(t, err, repositories.ErrChatNotFound)
+
+	var roleChanges int
+	for _, event := range f.audit.Published() {
+		if event.ChatID == chatID && event.Type == domain.EventRoleChanged && event.Details["new_role"] == domain.Owner {
+			roleChanges++
+		}
+	}
+	if roleChanges != 2 {
+		t.Errorf("recorded %d ownership changes, want 2", roleChanges)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	f := newFixture(t)
+	owner, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	outsider, _ := f.register("outsider")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID)
+
+	err := f.chatManagement.TransferOwnership(f.ctx, chatID, memberSession, member.ID)
+	wantCode(t, err, domain.CodeForbidden)
+	err = f.chatManagement.TransferOwnership(f.ctx, chatID, ownerSession, outsider.ID)
+	wantCode(t, err, domain.CodeInvalidArgument)
+	err = f.chatManagement.TransferOwnership(f.ctx, chatID, ownerSession, owner.ID)
+	wantCode(t, err, domain.CodeConflict)
+
+	if err = f.chatManagement.TransferOwnership(f.ctx, chatID, ownerSession, member.ID); err != nil {
+		t.Fatalf("TransferOwnership: %v", err)
+	}
+	chat := f.chat(chatID)
+	if chat.RoleOf(member.ID) != domain.Owner || chat.RoleOf(owner.ID) != domain.Admin {
+		t.Errorf("roles = %q and %q, want the member to own the chat and the old owner to be an admin",
+			chat.RoleOf(member.ID), chat.RoleOf(owner.ID))
+	}
+	for sessionID, want := range map[domain.ID]string{ownerSession: domain.Admin, memberSession: domain.Owner} {
+		if role, err := f.sessionService.IsUserInChat(f.ctx, sessionID, chatID); err != nil || role != want {
+			t.Errorf("session role = %q, %v, want %q", role, err, want)
+		}
+	}
+
+	// Private chats have no owner to hand over.
+	private, err := f.chatManagement.CreatePrivateChat(f.ctx, member.ID, ownerSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.chatManagement.LeaveChat(f.ctx, private, ownerSession)
+	wantCode(t, err, domain.CodeInvalidArgument)
+	err = f.chatManagement.TransferOwnership(f.ctx, private, ownerSession, member.ID)
+	wantCode(t, err, domain.CodeForbidden)
+}
@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"context"
+	"errors"
+	"strings"
+)
+
+type ContactManagement struct {
+	UserService    *services.UserService
+	ContactService *services.ContactService
+	SessionService *services.SessionService
+}
+
+func NewContactManagement(userService *services.UserService, contactService *services.ContactService, sessionService *services.SessionService) *ContactManagement {
+	return &ContactManagement{
+		UserService:    userService,
+		ContactService: contactService,
+		SessionService: sessionService,
+	}
+}
+
+// AddContact adds a user found by username or email. pending is true when
+// a contact request was sent instead. Users that SearchUsers would not show
+// the caller are reported as not found.
+func (cm *ContactManagement) AddContact(ctx context.Context, sessionID domain.ID, usernameOrEmail string) (pending bool, err error) {
+	user, err := cm.currentUser(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	contact, err := cm.findContact(ctx, user, usernameOrEmail)
+	if err != nil {
+		return false, err
+	}
+	return cm.ContactService.AddContact(ctx, user, contact.ID)
+}
+
+func (cm *ContactManagement) RemoveContact(ctx context.Context, sessionID, contactID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return cm.ContactService.RemoveContact(ctx, session.UserID, contactID)
+}
+
+// ListContacts returns the caller's contacts with their display names and
+// whether they currently have an active session.
+func (cm *ContactManagement) ListContacts(ctx context.Context, sessionID domain.ID) ([]domain.Contact, error) {
+	user, err := cm.currentUser(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var contacts []domain.Contact
+	for _, contactID := range user.Contacts {
+		profile, err := cm.UserService.GetPublicProfile(ctx, contactID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		_, err = cm.SessionService.GetSessionByUserID(ctx, contactID)
+		contacts = append(contacts, domain.Contact{
+			Profile: profile,
+			Online:  err == nil,
+		})
+	}
+	return contacts, nil
+}
+
+// ImportContacts adds every registered user whose email is in the list and
+// returns the ones that matched. Unknown emails are skipped, and so are
+// users that SearchUsers would not show the caller.
+func (cm *ContactManagement) ImportContacts(ctx context.Context, sessionID domain.ID, emails []string) ([]domain.PublicProfile, error) {
+	user, err := cm.currentUser(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[domain.ID]bool, len(user.Contacts)+1)
+	known[user.ID] = true
+	for _, contactID := range user.Contacts {
+		known[contactID] = true
+	}
+
+	var matched []domain.PublicProfile
+	for _, email := range emails {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(email, "@") {
+			continue
+		}
+		contact, err := cm.findContact(ctx, user, email)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if known[contact.ID] {
+			continue
+		}
+		known[contact.ID] = true
+
+		if _, err = cm.ContactService.AddContact(ctx, user, contact.ID); err != nil {
+			return nil, err
+		}
+		matched = append(matched, contact.PublicProfile())
+	}
+	return matched, nil
+}
+
+func (cm *ContactManagement) ListContactRequests(ctx context.Context, sessionID domain.ID) ([]domain.ContactRequest, error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return cm.ContactService.ListContactRequests(ctx, session.UserID)
+}
+
+func (cm *ContactManagement) AcceptContactRequest(ctx context.Context, sessionID, fromUserID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return cm.ContactService.RespondToRequest(ctx, session.UserID, fromUserID, true)
+}
+
+func (cm *ContactManagement) DeclineContactRequest(ctx context.Context, sessionID, fromUserID domain.ID) error {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return cm.ContactService.RespondToRequest(ctx, session.UserID, fromUserID, false)
+}
+
+// findContact looks a user up by username or email and applies the same
+// filters as SearchUsers: deleted users, users blocked either way and users
+// whose privacy settings hide them from the caller are not found. Looking
+// oneself up is left to ContactService to reject.
+func (cm *ContactManagement) findContact(ctx context.Context, user domain.User, usernameOrEmail string) (domain.User, error) {
+	contact, err := cm.UserService.FindUser(ctx, usernameOrEmail)
+	if err != nil {
+		return domain.User{}, err
+	}
+	exactEmail := strings.Contains(usernameOrEmail, "@")
+	if contact.ID != user.ID && !contact.CanBeFoundBy(user, exactEmail) {
+		return domain.User{}, domain.Wrap(repositories.ErrUserNotFound, "user %s not found", strings.TrimSpace(usernameOrEmail))
+	}
+	return contact, nil
+}
+
+func (cm *ContactManagement) currentUser(ctx context.Context, sessionID domain.ID) (domain.User, error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return cm.UserService.GetUserInfo(ctx, session.UserID)
+}
@@ -0,0 +1,201 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAddContactHonoursSearchFilters(t *testing.T) {
+	f := newFixture(t)
+	caller, callerSession := f.register("caller")
+	_, _ = f.register("open")
+	hidden, _ := f.register("hidden")
+	emailOnly, _ := f.register("email_only")
+	blocker, _ := f.register("blocker")
+	blocked, _ := f.register("blocked")
+	deleted, _ := f.register("deleted")
+
+	f.setDiscoverability(hidden.ID, domain.NotDiscoverable)
+	f.setDiscoverability(emailOnly.ID, domain.DiscoverableByEmailOnly)
+	if err := f.users.BlockUser(f.ctx, blocker.ID, caller.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.users.BlockUser(f.ctx, caller.ID, blocked.ID); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := f.users.SetDeletedTime(f.ctx, deleted.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		identifier string
+		wantCode   domain.ErrorCode
+	}{
+		{"open", ""},
+		{"hidden", domain.CodeNotFound},
+		{"hidden@example.com", domain.CodeNotFound},
+		{"email_only", domain.CodeNotFound},
+		{"email_only@example.com", ""},
+		{"blocker", domain.CodeNotFound},
+		{"blocked@example.com", domain.CodeNotFound},
+		{"deleted", domain.CodeNotFound},
+		{"caller", domain.CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			_, err := f.contactManagement.AddContact(f.ctx, callerSession, tt.identifier)
+			wantCode(t, err, tt.wantCode)
+			if tt.wantCode == domain.CodeNotFound {
+				wantIs(t, err, repositories.ErrUserNotFound)
+			}
+		})
+	}
+}
+
+func TestImportContactsHonoursSearchFilters(t *testing.T) {
+	f := newFixture(t)
+	caller, callerSession := f.register("caller")
+	open, _ := f.register("open")
+	emailOnly, _ := f.register("email_only")
+	hidden, _ := f.register("hidden")
+	blocker, _ := f.register("blocker")
+
+	f.setDiscoverability(emailOnly.ID, domain.DiscoverableByEmailOnly)
+	f.setDiscoverability(hidden.ID, domain.NotDiscoverable)
+	if err := f.users.BlockUser(f.ctx, blocker.ID, caller.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	matched, err := f.contactManagement.ImportContacts(f.ctx, callerSession, []string{
+		open.Email, emailOnly.Email, hidden.Email, blocker.Email, "nobody@example.com", "not an email", caller.Email,
+	})
+	if err != nil {
+		t.Fatalf("ImportContacts: %v", err)
+	}
+	want := []domain.PublicProfile{open.PublicProfile(), emailOnly.PublicProfile()}
+	if !reflect.DeepEqual(matched, want) {
+		t.Errorf("matched = %v, want %v", matched, want)
+	}
+
+	stored, err := f.users.GetUserInfo(f.ctx, caller.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []domain.ID{open.ID, emailOnly.ID}; !reflect.DeepEqual(stored.Contacts, want) {
+		t.Errorf("contacts = %v, want %v", stored.Contacts, want)
+	}
+}
+
+func TestContactRequests(t *testing.T) {
+	f := newFixture(t)
+	f.contactService.RequireAcceptance = true
+	alice, aliceSession := f.register("alice")
+	bob, bobSession := f.register("bob")
+	carol, carolSession := f.register("carol")
+
+	for range 2 {
+		pending, err := f.contactManagement.AddContact(f.ctx, aliceSession, "bob")
+		if err != nil || !pending {
+			t.Fatalf("AddContact = %v, %v, want a pending request", pending, err)
+		}
+	}
+	requests, err := f.contactManagement.ListContactRequests(f.ctx, bobSession)
+	if err != nil {
+		t.Fatalf("ListContactRequests: %v", err)
+	}
+	if len(requests) != 1 || requests[0].FromUserID != alice.ID {
+		t.Fatalf("bob's requests = %+v, want one from alice", requests)
+	}
+	f.wantContacts(alice.ID)
+	f.wantContacts(bob.ID)
+
+	if err = f.contactManagement.AcceptContactRequest(f.ctx, bobSession, alice.ID); err != nil {
+		t.Fatalf("AcceptContactRequest: %v", err)
+	}
+	f.wantContacts(alice.ID, bob.ID)
+	f.wantContacts(bob.ID, alice.ID)
+	err = f.contactManagement.AcceptContactRequest(f.ctx, bobSession, alice.ID)
+	wantIs(t, err, repositories.ErrContactRequestNotFound)
+
+	// A request that crosses one from the other user is accepted at once.
+	if pending, err := f.contactManagement.AddContact(f.ctx, carolSession, "alice"); err != nil || !pending {
+		t.Fatalf("AddContact = %v, %v, want a pending request", pending, err)
+	}
+	if pending, err := f.contactManagement.AddContact(f.ctx, aliceSession, "carol"); err != nil || pending {
+		t.Fatalf("crossing AddContact = %v, %v, want it accepted", pending, err)
+	}
+	f.wantContacts(alice.ID, bob.ID, carol.ID)
+	f.wantContacts(carol.ID, alice.ID)
+
+	if pending, err := f.contactManagement.AddContact(f.ctx, carolSession, "bob"); err != nil || !pending {
+		t.Fatalf("AddContact = %v, %v, want a pending request", pending, err)
+	}
+	if err = f.contactManagement.DeclineContactRequest(f.ctx, bobSession, carol.ID); err != nil {
+		t.Fatalf("DeclineContactRequest: %v", err)
+	}
+	if requests, _ = f.contactManagement.ListContactRequests(f.ctx, bobSession); len(requests) != 0 {
+		t.Errorf("bob's requests after declining = %+v, want none", requests)
+	}
+	f.wantContacts(carol.ID, alice.ID)
+
+	if err = f.contactManagement.RemoveContact(f.ctx, aliceSession, bob.ID); err != nil {
+		t.Fatalf("RemoveContact: %v", err)
+	}
+	f.wantContacts(alice.ID, carol.ID)
+	f.wantContacts(bob.ID)
+}
+
+func TestListContactsShowsPresence(t *testing.T) {
+	f := newFixture(t)
+	_, aliceSession := f.register("alice")
+	bob, _ := f.register("bob")
+	carol, _ := f.register("carol")
+	for _, name := range []string{"bob", "carol"} {
+		if _, err := f.contactManagement.AddContact(f.ctx, aliceSession, name); err != nil {
+			t.Fatalf("AddContact %s: %v", name, err)
+		}
+	}
+	if err := f.sessionService.DeleteUserSessions(f.ctx, carol.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := f.contactManagement.ListContacts(f.ctx, aliceSession)
+	if err != nil {
+		t.Fatalf("ListContacts: %v", err)
+	}
+	want := []domain.Contact{
+		{Profile: bob.PublicProfile(), Online: true},
+		{Profile: carol.PublicProfile(), Online: false},
+	}
+	if !reflect.DeepEqual(contacts, want) {
+		t.Errorf("contacts = %+v, want %+v", contacts, want)
+	}
+}
+
+// wantContacts fails the test unless the user's contacts are exactly want.
+func (f *fixture) wantContacts(userID domain.ID, want ...domain.ID) {
+	f.t.Helper()
+	user, err := f.users.GetUserInfo(f.ctx, userID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if len(user.Contacts) != len(want) || (len(want) > 0 && !reflect.DeepEqual(user.Contacts, want)) {
+		f.t.Errorf("%s's contacts = %v, want %v", user.Username, user.Contacts, want)
+	}
+}
+
+func (f *fixture) setDiscoverability(userID domain.ID, discoverability domain.Discoverability) {
+	f.t.Helper()
+	user, err := f.users.GetUserInfo(f.ctx, userID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	user.Privacy.Discoverability = discoverability
+	if err = f.users.UpdateUser(f.ctx, user); err != nil {
+		f.t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"context"
+	"errors"
+	"time"
+)
+
+// DataExportManagement lets users download a copy of everything stored
+// about them. Archives are built in the background by
+// ProcessPendingExports, which app.Start runs periodically.
+type DataExportManagement struct {
+	UserService    *services.UserService
+	ChatService    *services.ChatService
+	MessageService *services.MessageService
+	SessionService *services.SessionService
+	ExportService  *services.ExportService
+}
+
+func NewDataExportManagement(userService *services.UserService, chatService *services.ChatService, messageService *services.MessageService, sessionService *services.SessionService, exportService *services.ExportService) *DataExportManagement {
+	return &DataExportManagement{
+		UserService:    userService,
+		ChatService:    chatService,
+		MessageService: messageService,
+		SessionService: sessionService,
+		ExportService:  exportService,
+	}
+}
+
+type membershipExport struct {
+	ChatID     domain.ID
+	Name       string
+	ChatType   domain.ChatType
+	Role       string
+	JoinedTime *time.Time
+}
+
+// sessionExport leaves the session ID out: it would let anyone holding the
+// archive use the session.
+type sessionExport struct {
+	UserID domain.ID
+	Chats  []string
+}
+
+// RequestExport queues an export of the caller's data. The returned export
+// carries the token needed to download it once it is ready.
+func (dm *DataExportManagement) RequestExport(ctx context.Context, sessionID domain.ID) (domain.DataExport, error) {
+	session, err := dm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+	return dm.ExportService.CreateExport(ctx, session.UserID)
+}
+
+func (dm *DataExportManagement) GetExport(ctx context.Context, sessionID, exportID domain.ID) (domain.DataExport, error) {
+	session, err := dm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+	return dm.ExportService.FindExport(ctx, exportID, session.UserID)
+}
+
+// DownloadExport returns the zip archive. It only works for the user who
+// requested the export, with its token, and only once.
+func (dm *DataExportManagement) DownloadExport(ctx context.Context, sessionID, exportID domain.ID, token string) ([]byte, error) {
+	session, err := dm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return dm.ExportService.Download(ctx, exportID, session.UserID, token)
+}
+
+// ProcessPendingExports builds the archives of all pending exports. An
+// export whose data cannot be collected is marked as failed.
+func (dm *DataExportManagement) ProcessPendingExports(ctx context.Context) error {
+	exports, err := dm.ExportService.ListPendingExports(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sections, err := dm.collect(ctx, export.UserID)
+		if err != nil {
+			if err = dm.ExportService.Fail(ctx, export, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err = dm.ExportService.Complete(ctx, export, sections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dm *DataExportManagement) PurgeExpiredExports(ctx context.Context) error {
+	_, err := dm.ExportService.PurgeExpired(ctx)
+	return err
+}
+
+func (dm *DataExportManagement) collect(ctx context.Context, userID domain.ID) ([]services.ExportSection, error) {
+	user, err := dm.UserService.GetUserInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var contacts []domain.PublicProfile
+	for _, contactID := range user.Contacts {
+		profile, err := dm.UserService.GetPublicProfile(ctx, contactID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		contacts = append(contacts, profile)
+	}
+
+	memberships, err := dm.collectMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := dm.MessageService.ListUserMessages(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userSessions, err := dm.SessionService.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []sessionExport
+	for _, session := range userSessions {
+		sessions = append(sessions, sessionExport{UserID: session.UserID, Chats: session.ChatNameList})
+	}
+
+	return []services.ExportSection{
+		{Name: "profile.json", Description: "account and profile details", Entries: 1, Data: user},
+		{Name: "contacts.json", Description: "public profiles of your contacts", Entries: len(contacts), Data: contacts},
+		{Name: "memberships.json", Description: "chats you belong to and your role in them", Entries: len(memberships), Data: memberships},
+		{Name: "messages.json", Description: "messages you have sent; messages have no reactions, so there are none to export", Entries: len(messages), Data: messages},
+		{Name: "sessions.json", Description: "all of your active sessions", Entries: len(sessions), Data: sessions},
+	}, nil
+}
+
+func (dm *DataExportManagement) collectMemberships(ctx context.Context, userID domain.ID) ([]membershipExport, error) {
+	chatIDList, err := dm.UserService.GetChatIDList(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []membershipExport
+	for _, chatID := range chatIDList {
+		chat, err := dm.ChatService.FindChat(ctx, domain.ID(chatID))
+		if err != nil {
+			if errors.Is(err, repositories.ErrChatNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if !chat.IsMember(userID) {
+			continue
+		}
+		name, err := chatNameFor(ctx, dm.UserService, chat, userID)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membershipExport{
+			ChatID:     chat.ID,
+			Name:       name,
+			ChatType:   chat.ChatType,
+			Role:       chat.RoleOf(userID),
+			JoinedTime: chat.JoinedTime(userID),
+		})
+	}
+	return memberships, nil
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"archive/zip"
+	"bytes"
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"chat-app/internal/infrastructure/memory"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataExport(t *testing.T) {
+	f := newFixture(t)
+	user, phone := f.register("exporter")
+	friend, friendSession := f.register("friend")
+	chatID := f.newChat(domain.Group, "team", friendSession, user.ID)
+	laptop := f.login(user.ID)
+	if err := f.messageService.SendMessage(f.ctx, chatID, user.ID, "hello team"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.contactService.AddContact(f.ctx, user, friend.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	dm := NewDataExportManagement(f.userService, f.chatService, f.messageService, f.sessionService, services.NewExportService(memory.NewExportRepository()))
+	export, err := dm.RequestExport(f.ctx, phone)
+	if err != nil {
+		t.Fatalf("RequestExport: %v", err)
+	}
+	_, err = dm.DownloadExport(f.ctx, phone, export.ID, export.Token)
+	wantIs(t, err, repositories.ErrExportNotAvailable)
+
+	if err = dm.ProcessPendingExports(f.ctx); err != nil {
+		t.Fatalf("ProcessPendingExports: %v", err)
+	}
+	_, err = dm.DownloadExport(f.ctx, friendSession, export.ID, export.Token)
+	wantCode(t, err, domain.CodeNotFound)
+	archive, err := dm.DownloadExport(f.ctx, laptop, export.ID, export.Token)
+	if err != nil {
+		t.Fatalf("DownloadExport: %v", err)
+	}
+	_, err = dm.DownloadExport(f.ctx, phone, export.ID, export.Token)
+	wantIs(t, err, repositories.ErrExportNotAvailable)
+
+	files := unzip(t, archive)
+	var manifest domain.ExportManifest
+	decode(t, files, "manifest.json", &manifest)
+	entries := make(map[string]int)
+	for _, file := range manifest.Files {
+		entries[file.Name] = file.Entries
+		if file.Name == "messages.json" && !strings.Contains(file.Description, "no reactions") {
+			t.Errorf("messages.json description %q does not say that messages have no reactions", file.Description)
+		}
+	}
+	want := map[string]int{"profile.json": 1, "contacts.json": 1, "memberships.json": 1, "messages.json": 1, "sessions.json": 2}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("manifest entries = %v, want %v", entries, want)
+	}
+
+	var sessions []sessionExport
+	decode(t, files, "sessions.json", &sessions)
+	for _, session := range sessions {
+		if session.UserID != user.ID || !reflect.DeepEqual(session.Chats, []string{"team"}) {
+			t.Errorf("session = %+v, want the user's session listing team", session)
+		}
+	}
+	for name, content := range files {
+		if strings.Contains(string(content), string(phone)) || strings.Contains(string(content), string(laptop)) {
+			t.Errorf("%s contains a session ID", name)
+		}
+	}
+
+	var profile domain.User
+	decode(t, files, "profile.json", &profile)
+	if profile.ID != user.ID || profile.Password != "" {
+		t.Errorf("profile = %+v, want the user without a password", profile)
+	}
+}
+
+func unzip(t *testing.T, archive []byte) map[string][]byte {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range r.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = content
+	}
+	return files
+}
+
+func decode(t *testing.T, files map[string][]byte, name string, v any) {
+	t.Helper()
+	content, ok := files[name]
+	if !ok {
+		t.Fatalf("archive has no %s", name)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
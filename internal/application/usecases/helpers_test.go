@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"chat-app/internal/infrastructure/mail"
+	"chat-app/internal/infrastructure/memory"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const testPassword = "correct horse 1"
+
+// fixture wires the use cases on top of the in-memory stores.
+type fixture struct {
+	t        *testing.T
+	ctx      context.Context
+	users    *memory.UserRepository
+	chats    *memory.ChatRepository
+	messages *memory.MessageRepository
+	sessions *memory.SessionRepository
+	invites  *memory.InviteRepository
+	audit    *memory.AuditRepository
+	contacts *memory.ContactRepository
+
+	userService       *services.UserService
+	chatService       *services.ChatService
+	messageService    *services.MessageService
+	sessionService    *services.SessionService
+	inviteService     *services.InviteService
+	auditService      *services.AuditService
+	contactService    *services.ContactService
+	moderationService *services.ModerationService
+	rateLimiter       *services.RateLimiter
+
+	userManagement    *UserManagement
+	chatManagement    *ChatManagement
+	contactManagement *ContactManagement
+	messaging         *Messaging
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		t:        t,
+		ctx:      context.Background(),
+		messages: memory.NewMessageRepository(),
+		sessions: memory.NewSessionRepository(),
+		invites:  memory.NewInviteRepository(),
+		audit:    memory.NewAuditRepository(),
+	}
+	f.chats = memory.NewChatRepository(f.messages)
+	f.users = memory.NewUserRepository(f.chats)
+	f.contacts = memory.NewContactRepository(f.users)
+
+	f.userService = services.NewUserService(f.users)
+	f.chatService = services.NewChatService(f.chats)
+	f.messageService = services.NewMessageService(f.messages, services.NewMessageIndex())
+	f.sessionService = services.NewSessionService(f.sessions)
+	f.inviteService = services.NewInviteService(f.invites)
+	f.auditService = services.NewAuditService(f.audit, f.audit)
+	f.moderationService = services.NewModerationService(memory.NewRestrictionRepository())
+	f.rateLimiter = services.NewRateLimiter(memory.NewRateLimitStore())
+
+	twoFactorService := services.NewTwoFactorService(memory.NewTwoFactorRepository(), "chat-app", false)
+	loginGuard := services.NewLoginGuardService(memory.NewLoginAttemptStore())
+	f.userManagement = NewUserManagement(f.userService, f.chatService, f.sessionService, twoFactorService, loginGuard, f.rateLimiter, mail.NewMemorySink())
+	f.chatManagement = NewChatManagement(f.chatService, f.sessionService, f.userService, f.inviteService, f.auditService, f.moderationService, f.rateLimiter)
+	f.messaging = NewMessaging(f.chatService, f.messageService, f.sessionService, f.moderationService, f.userService, f.rateLimiter)
+	f.contactService = services.NewContactService(f.contacts, false)
+	f.contactManagement = NewContactManagement(f.userService, f.contactService, f.sessionService)
+	return f
+}
+
+// register signs a user up with a verified email; signing up logs them in.
+func (f *fixture) register(username string) (domain.User, domain.ID) {
+	f.t.Helper()
+	now := time.Now()
+	born := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := domain.User{
+		ID:                domain.ID(uuid.New().String()),
+		Username:          username,
+		FirstName:         username,
+		LastName:          "Tester",
+		Password:          testPassword,
+		Gender:            domain.NonBinary,
+		Email:             username + "@example.com",
+		EmailVerifiedTime: &now,
+		DateOfBirth:       &born,
+		CreatedTime:       &now,
+	}
+	session, err := f.userManagement.Register(f.ctx, user)
+	if err != nil {
+		f.t.Fatalf("register %s: %v", username, err)
+	}
+	return user, session.SessionID
+}
+
+// login opens a session listing the user's chats.
+func (f *fixture) login(userID domain.ID) domain.ID {
+	f.t.Helper()
+	session, err := f.userManagement.newSession(f.ctx, userID)
+	if err != nil {
+		f.t.Fatalf("new session: %v", err)
+	}
+	return session.SessionID
+}
+
+// newChat creates a group or channel owned by the session's user, with the
+// given users added as members.
+func (f *fixture) newChat(chatType domain.ChatType, name string, ownerSession domain.ID, members ...domain.ID) domain.ID {
+	f.t.Helper()
+	return f.createChat(domain.Chat{Name: name, ChatType: chatType}, ownerSession, members...)
+}
+
+// createChat creates the chat owned by the session's user, filling in its
+// ID, owner and creation time, and adds the given users as members.
+func (f *fixture) createChat(chat domain.Chat, ownerSession domain.ID, members ...domain.ID) domain.ID {
+	f.t.Helper()
+	now := time.Now()
+	chat.ID = domain.ID(uuid.New().String())
+	chat.Owner = f.session(ownerSession).UserID
+	chat.Members = []domain.ID{chat.Owner}
+	chat.CreatedTime = &now
+	if err := f.chatManagement.CreateChat(f.ctx, chat, ownerSession); err != nil {
+		f.t.Fatalf("create chat %s: %v", chat.Name, err)
+	}
+	if len(members) > 0 {
+		if err := f.chatManagement.addMembers(f.ctx, chat, members); err != nil {
+			f.t.Fatalf("add members to %s: %v", chat.Name, err)
+		}
+	}
+	return chat.ID
+}
+
+// wantRevoked fails the test unless every given session is gone.
+func (f *fixture) wantRevoked(sessionIDs ...domain.ID) {
+	f.t.Helper()
+	for _, sessionID := range sessionIDs {
+		if _, err := f.sessionService.GetSession(f.ctx, sessionID); !errors.Is(err, repositories.ErrSessionNotFound) {
+			f.t.Errorf("session %s: GetSession = %v, want ErrSessionNotFound", sessionID, err)
+		}
+	}
+}
+
+func (f *fixture) session(sessionID domain.ID) domain.Session {
+	f.t.Helper()
+	session, err := f.sessionService.GetSession(f.ctx, sessionID)
+	if err != nil {
+		f.t.Fatalf("get session: %v", err)
+	}
+	return session
+}
+
+func (f *fixture) chat(chatID domain.ID) domain.Chat {
+	f.t.Helper()
+	chat, err := f.chats.FindChat(f.ctx, chatID)
+	if err != nil {
+		f.t.Fatalf("find chat: %v", err)
+	}
+	return chat
+}
+
+// wantCode fails the test unless err has the given code; CodeOf(nil) is
+// taken to be "".
+func wantCode(t *testing.T, err error, want domain.ErrorCode) {
+	t.Helper()
+	var got domain.ErrorCode
+	if err != nil {
+		got = domain.CodeOf(err)
+	}
+	if got != want {
+		t.Fatalf("error = %v (code %q), want code %q", err, got, want)
+	}
+}
+
+func wantIs(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"context"
+	"time"
+)
+
+func (cm *ChatManagement) CreateInvite(ctx context.Context, chatID, sessionID domain.ID, ttl time.Duration, usageLimit int) (domain.Invite, error) {
+	session, err := cm.authorizeInviteManagement(ctx, chatID, sessionID)
+	if err != nil {
+		return domain.Invite{}, err
+	}
+	if err = services.ValidateInviteOptions(ttl, usageLimit); err != nil {
+		return domain.Invite{}, err
+	}
+	if err = cm.UserService.CheckEmailVerified(ctx, session.UserID); err != nil {
+		return domain.Invite{}, err
+	}
+	if err = allowUser(ctx, cm.RateLimiter, cm.UserService, domain.OpCreateInvite, session.UserID); err != nil {
+		return domain.Invite{}, err
+	}
+	return cm.InviteService.CreateInvite(ctx, chatID, session.UserID, ttl, usageLimit)
+}
+
+func (cm *ChatManagement) ListInvites(ctx context.Context, chatID, sessionID domain.ID) ([]domain.Invite, error) {
+	if _, err := cm.authorizeInviteManagement(ctx, chatID, sessionID); err != nil {
+		return nil, err
+	}
+	return cm.InviteService.ListInvites(ctx, chatID)
+}
+
+func (cm *ChatManagement) RevokeInvite(ctx context.Context, chatID, sessionID domain.ID, code string) error {
+	if _, err := cm.authorizeInviteManagement(ctx, chatID, sessionID); err != nil {
+		return err
+	}
+
+	invite, err := cm.InviteService.FindInvite(ctx, code)
+	if err != nil {
+		return err
+	}
+	if invite.ChatID != chatID {
+		return domain.NotFound("invite does not belong to this chat")
+	}
+	return cm.InviteService.RevokeInvite(ctx, code)
+}
+
+// JoinByInvite adds the caller to the invite's chat. If the chat requires
+// approval a pending join request is created instead and joined is false.
+// The invite is only used once every other check has passed.
+func (cm *ChatManagement) JoinByInvite(ctx context.Context, code string, sessionID domain.ID) (joined bool, err error) {
+	session, err := cm.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	invite, err := cm.InviteService.FindInvite(ctx, code)
+	if err != nil {
+		return false, err
+	}
+	if !invite.IsUsable(time.Now()) {
+		return false, repositories.ErrInviteNotUsable
+	}
+	chat, err := cm.ChatService.FindChat(ctx, invite.ChatID)
+	if err != nil {
+		return false, err
+	}
+	if chat.ChatType == domain.Private {
+		return false, domain.InvalidArgument("private chats cannot be joined by invite")
+	}
+	if chat.IsMember(session.UserID) {
+		return false, domain.Conflict("user is already a member of %s", chat.Name)
+	}
+	if err = cm.ModerationService.CheckCanJoin(ctx, chat.ID, session.UserID); err != nil {
+		return false, err
+	}
+
+	if chat.Settings.JoinApproval {
+		// Checked up front so that a duplicate request does not use the
+		// invite; CreateJoinRequest still rejects one that races past.
+		if err = cm.InviteService.CheckCanRequestJoin(ctx, chat.ID, session.UserID); err != nil {
+			return false, err
+		}
+		if _, err = cm.InviteService.UseInvite(ctx, code); err != nil {
+			return false, err
+		}
+		_, err = cm.InviteService.CreateJoinRequest(ctx, chat.ID, session.UserID, code)
+		return false, err
+	}
+
+	if _, err = cm.InviteService.UseInvite(ctx, code); err != nil {
+		return false, err
+	}
+	if err = cm.addMembers(ctx, chat, []domain.ID{session.UserID}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (cm *ChatManagement) ListJoinRequests(ctx context.Context, chatID, sessionID domain.ID) ([]domain.JoinRequest, error) {
+	if _, err := cm.authorizeInviteManagement(ctx, chatID, sessionID); err != nil {
+		return nil, err
+	}
+	return cm.InviteService.ListPendingJoinRequests(ctx, chatID)
+}
+
+func (cm *ChatManagement) ApproveJoinRequest(ctx context.Context, chatID, sessionID, requestID domain.ID) error {
+	if _, err := cm.authorizeInviteManagement(ctx, chatID, sessionID); err != nil {
+		return err
+	}
+
+	request, err := cm.InviteService.ResolveJoinRequest(ctx, chatID, requestID, domain.Approved)
+	if err != nil {
+		return err
+	}
+
+	chat, err := cm.ChatService.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.IsMember(request.UserID) {
+		return nil
+	}
+	return cm.addMembers(ctx, chat, []domain.ID{request.UserID})
+}
+
+func (cm *ChatManagement) RejectJoinRequest(ctx context.Context, chatID, sessionID, requestID domain.ID) error {
+	if _, err := cm.authorizeInviteManagement(ctx, chatID, sessionID); err != nil {
+		return err
+	}
+
+	_, err := cm.InviteService.ResolveJoinRequest(ctx, chatID, requestID, domain.Rejected)
+	return err
+}
+
+func (cm *ChatManagement) authorizeInviteManagement(ctx context.Context, chatID, sessionID domain.ID) (domain.Session, error) {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, domain.PermManageInvites)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.Session{}, domain.InvalidArgument("private chats do not have invites")
+	}
+	return session, nil
+}
@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"testing"
+	"time"
+)
+
+func TestJoinByInvite(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	joiner, joinerSession := f.register("joiner")
+	_, otherSession := f.register("other")
+	chatID := f.newChat(domain.Group, "team", ownerSession)
+
+	invite, err := f.chatManagement.CreateInvite(f.ctx, chatID, ownerSession, time.Hour, 1)
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+
+	joined, err := f.chatManagement.JoinByInvite(f.ctx, invite.Code, joinerSession)
+	if err != nil || !joined {
+		t.Fatalf("JoinByInvite = %v, %v, want true", joined, err)
+	}
+	if !f.chat(chatID).IsMember(joiner.ID) {
+		t.Error("joiner is not a member")
+	}
+	if _, ok := f.session(joinerSession).ChatIDAndName[string(chatID)]; !ok {
+		t.Error("chat is not listed in the joiner's session")
+	}
+
+	_, err = f.chatManagement.JoinByInvite(f.ctx, invite.Code, otherSession)
+	wantIs(t, err, repositories.ErrInviteNotUsable)
+}
+
+func TestJoinByInviteWithApproval(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	joiner, joinerSession := f.register("joiner")
+	chatID := f.newChat(domain.Group, "team", ownerSession)
+
+	approval := true
+	if _, err := f.chatManagement.UpdateChat(f.ctx, chatID, ownerSession, domain.ChatUpdate{JoinApproval: &approval}); err != nil {
+		t.Fatal(err)
+	}
+	invite, err := f.chatManagement.CreateInvite(f.ctx, chatID, ownerSession, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined, err := f.chatManagement.JoinByInvite(f.ctx, invite.Code, joinerSession)
+	if err != nil || joined {
+		t.Fatalf("JoinByInvite = %v, %v, want a pending request", joined, err)
+	}
+	_, err = f.chatManagement.JoinByInvite(f.ctx, invite.Code, joinerSession)
+	wantIs(t, err, repositories.ErrJoinRequestPending)
+
+	stored, err := f.inviteService.FindInvite(f.ctx, invite.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.UsageCount != 1 {
+		t.Errorf("invite used %d times, want 1: a duplicate request must not use it", stored.UsageCount)
+	}
+
+	requests, err := f.chatManagement.ListJoinRequests(f.ctx, chatID, ownerSession)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("ListJoinRequests = %v, %v, want one request", requests, err)
+	}
+	if err = f.chatManagement.ApproveJoinRequest(f.ctx, chatID, ownerSession, requests[0].ID); err != nil {
+		t.Fatalf("ApproveJoinRequest: %v", err)
+	}
+	if !f.chat(chatID).IsMember(joiner.ID) {
+		t.Error("approved user is not a member")
+	}
+	err = f.chatManagement.ApproveJoinRequest(f.ctx, chatID, ownerSession, requests[0].ID)
+	wantCode(t, err, domain.CodeConflict)
+}
+
+func TestJoinByInviteRejected(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	_, joinerSession := f.register("joiner")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID)
+
+	_, err := f.chatManagement.CreateInvite(f.ctx, chatID, memberSession, 0, 0)
+	wantCode(t, err, domain.CodeForbidden)
+
+	revoked, err := f.chatManagement.CreateInvite(f.ctx, chatID, ownerSession, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.chatManagement.RevokeInvite(f.ctx, chatID, ownerSession, revoked.Code); err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.chatManagement.JoinByInvite(f.ctx, revoked.Code, joinerSession)
+	wantIs(t, err, repositories.ErrInviteNotUsable)
+
+	// Private chats have no invites, even if one made it into the store.
+	private, err := f.chatManagement.CreatePrivateChat(f.ctx, member.ID, ownerSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err = f.invites.CreateInvite(f.ctx, domain.Invite{Code: "private", ChatID: private, CreatedTime: &now}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.chatManagement.JoinByInvite(f.ctx, "private", joinerSession)
+	wantCode(t, err, domain.CodeInvalidArgument)
+	if f.chat(private).IsMember(f.session(joinerSession).UserID) {
+		t.Error("joined a private chat by invite")
+	}
+}
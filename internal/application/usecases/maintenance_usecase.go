@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/services"
+	"context"
+	"errors"
+)
+
+// Maintenance groups the clean-up work meant to run periodically in the
+// background; app.Start schedules it with jobs.Every.
+type Maintenance struct {
+	ChatService       *services.ChatService
+	MessageService    *services.MessageService
+	ModerationService *services.ModerationService
+	UserService       *services.UserService
+	SessionService    *services.SessionService
+	ContactService    *services.ContactService
+	ChatManagement    *ChatManagement
+	// DeletedUserMessages decides whether an anonymized user's messages are
+	// kept or purged.
+	DeletedUserMessages domain.DeletedUserMessages
+}
+
+func NewMaintenance(chatService *services.ChatService, messageService *services.MessageService, moderationService *services.ModerationService, userService *services.UserService, sessionService *services.SessionService, contactService *services.ContactService, chatManagement *ChatManagement) *Maintenance {
+	return &Maintenance{
+		ChatService:         chatService,
+		MessageService:      messageService,
+		ModerationService:   moderationService,
+		UserService:         userService,
+		SessionService:      sessionService,
+		ContactService:      contactService,
+		ChatManagement:      chatManagement,
+		DeletedUserMessages: domain.KeepMessages,
+	}
+}
+
+// PurgeDeletedChats permanently removes chats, and their messages, whose
+// deletion grace period has passed.
+func (mt *Maintenance) PurgeDeletedChats(ctx context.Context) error {
+	chats, err := mt.ChatService.ListExpiredDeletedChats(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range chats {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err = mt.MessageService.DeleteChatMessages(ctx, chat.ID); err != nil {
+			return domain.Wrap(err, "failed to purge chat %v", chat.ID)
+		}
+		if err = mt.ChatService.PurgeChat(ctx, chat.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mt *Maintenance) PurgeExpiredRestrictions(ctx context.Context) error {
+	_, err := mt.ModerationService.PurgeExpired(ctx)
+	return err
+}
+
+// PurgeDeletedUsers anonymizes accounts whose deletion grace period has
+// passed: their sessions are revoked, they leave their groups and channels,
+// their contacts and contact requests are deleted, and their messages are
+// kept or purged according to DeletedUserMessages. A user that fails is
+// skipped and retried on the next run; the errors are returned together.
+func (mt *Maintenance) PurgeDeletedUsers(ctx context.Context) error {
+	users, err := mt.UserService.ListExpiredDeletedUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if err = mt.purgeDeletedUser(ctx, user.ID); err != nil {
+			errs = append(errs, domain.Wrap(err, "failed to purge user %v", user.ID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (mt *Maintenance) purgeDeletedUser(ctx context.Context, userID domain.ID) error {
+	if err := mt.SessionService.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := mt.ChatManagement.LeaveAllChats(ctx, userID); err != nil {
+		return err
+	}
+	if err := mt.ContactService.DeleteUserContacts(ctx, userID); err != nil {
+		return err
+	}
+	if mt.DeletedUserMessages == domain.PurgeMessages {
+		if err := mt.MessageService.DeleteUserMessages(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return mt.UserService.AnonymizeUser(ctx, userID)
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/services"
+	"testing"
+	"time"
+)
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	f := newFixture(t)
+	user, phone := f.register("leaving")
+	laptop := f.login(user.ID)
+	friend, friendSession := f.register("friend")
+	recent, recentSession := f.register("recent")
+	chatID := f.newChat(domain.Group, "team", friendSession, user.ID, recent.ID)
+
+	if _, err := f.contactService.AddContact(f.ctx, friend, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().Add(-services.DefaultAccountDeletionGracePeriod - time.Hour)
+	if err := f.users.SetDeletedTime(f.ctx, user.ID, &expired); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := f.users.SetDeletedTime(f.ctx, recent.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+
+	maintenance := NewMaintenance(f.chatService, f.messageService, f.moderationService, f.userService, f.sessionService, f.contactService, f.chatManagement)
+	if err := maintenance.PurgeDeletedUsers(f.ctx); err != nil {
+		t.Fatalf("PurgeDeletedUsers: %v", err)
+	}
+
+	f.wantRevoked(phone, laptop)
+	chat := f.chat(chatID)
+	if chat.IsMember(user.ID) {
+		t.Error("purged user is still a member")
+	}
+	stored, err := f.users.GetUserInfo(f.ctx, friend.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Contacts) != 0 {
+		t.Errorf("friend's contacts = %v, want none", stored.Contacts)
+	}
+	if stored, err = f.users.GetUserInfo(f.ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.AnonymizedTime == nil {
+		t.Error("purged user is not anonymized")
+	}
+
+	// Users still within the grace period are left alone.
+	f.session(recentSession)
+	if !chat.IsMember(recent.ID) {
+		t.Error("user in the grace period left the chat")
+	}
+}
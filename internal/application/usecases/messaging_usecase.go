@@ -4,66 +4,218 @@ import (
 	"chat-app/internal/core/domain"
 	"chat-app/internal/core/repositories"
 	"chat-app/internal/core/services"
+	"context"
 	"errors"
-	"fmt"
 )
 
 type Messaging struct {
-	ChatService    *services.ChatService
-	MessageService *services.MessageService
-	SessionService *services.SessionService
+	ChatService       *services.ChatService
+	MessageService    *services.MessageService
+	SessionService    *services.SessionService
+	ModerationService *services.ModerationService
+	UserService       *services.UserService
+	RateLimiter       *services.RateLimiter
 }
 
-func NewMessaging(chatService *services.ChatService, messageService *services.MessageService, sessionService *services.SessionService) *Messaging {
+func NewMessaging(chatService *services.ChatService, messageService *services.MessageService, sessionService *services.SessionService, moderationService *services.ModerationService, userService *services.UserService, rateLimiter *services.RateLimiter) *Messaging {
 	return &Messaging{
-		ChatService:    chatService,
-		MessageService: messageService,
-		SessionService: sessionService,
+		ChatService:       chatService,
+		MessageService:    messageService,
+		SessionService:    sessionService,
+		ModerationService: moderationService,
+		UserService:       userService,
+		RateLimiter:       rateLimiter,
 	}
 }
 
-func (m *Messaging) SendMessage(chatID domain.ID, sessionID domain.ID, message string) error {
-	isUserInChat, err := m.SessionService.IsUserInChat(sessionID, chatID)
+func (m *Messaging) SendMessage(ctx context.Context, chatID domain.ID, sessionID domain.ID, message string) error {
+	chat, session, err := m.authorize(ctx, chatID, sessionID, domain.PermSendMessages)
+	if err != nil {
+		return err
+	}
+	if err = services.ValidateMessage(message); err != nil {
+		return err
+	}
+	if err = m.ModerationService.CheckCanPost(ctx, chatID, session.UserID); err != nil {
+		return err
+	}
+	if err = m.ModerationService.ReservePost(ctx, chat, session.UserID); err != nil {
+		return err
+	}
+	if err = allowUser(ctx, m.RateLimiter, m.UserService, domain.OpSendMessage, session.UserID); err != nil {
+		return err
+	}
+
+	if err = m.MessageService.SendMessage(ctx, chatID, session.UserID, message); err != nil {
+		return err
+	}
+	return nil
 
+}
+
+func (m *Messaging) SendMedia(ctx context.Context, chatID, sessionID domain.ID, caption string, attachments []domain.Attachment) error {
+	chat, session, err := m.authorize(ctx, chatID, sessionID, domain.PermSendMedia)
 	if err != nil {
-		if errors.Is(err, repositories.ErrChatNotFound) {
-			return fmt.Errorf("user is not in chat or chat ID is wrong: %v", err)
-		}
 		return err
 	}
-	if isUserInChat == "" {
-		return fmt.Errorf("user role in chat is missing")
+	if err = services.ValidateMedia(caption, attachments); err != nil {
+		return err
+	}
+	if err = m.ModerationService.CheckCanPost(ctx, chatID, session.UserID); err != nil {
+		return err
+	}
+	if err = m.ModerationService.ReservePost(ctx, chat, session.UserID); err != nil {
+		return err
+	}
+	if err = allowUser(ctx, m.RateLimiter, m.UserService, domain.OpSendMessage, session.UserID); err != nil {
+		return err
 	}
 
-	session, err := m.SessionService.GetSession(sessionID)
+	return m.MessageService.SendMedia(ctx, chatID, session.UserID, caption, attachments)
+}
+
+// EditMessage lets the sender change their own message, unless they are
+// muted.
+func (m *Messaging) EditMessage(ctx context.Context, chatID, sessionID, messageID domain.ID, message string) error {
+	_, session, err := m.authorize(ctx, chatID, sessionID, domain.PermSendMessages)
 	if err != nil {
 		return err
 	}
 
-	if err = m.MessageService.SendMessage(chatID, session.UserID, message); err != nil {
+	original, err := m.findChatMessage(ctx, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	if original.SenderID != session.UserID {
+		return domain.Forbidden("only the sender can edit this message")
+	}
+	if err = m.ModerationService.CheckCanPost(ctx, chatID, session.UserID); err != nil {
 		return err
 	}
-	return nil
 
+	return m.MessageService.EditMessage(ctx, chatID, session.UserID, messageID, message)
 }
 
-func (m *Messaging) DeleteMessage(chatID domain.ID, sessionID domain.ID) error {
-	isUserInChat, err := m.SessionService.IsUserInChat(sessionID, chatID)
+// DeleteMessage deletes the caller's own message, or someone else's if the
+// caller has the delete_others_messages permission.
+func (m *Messaging) DeleteMessage(ctx context.Context, chatID, sessionID, messageID domain.ID) error {
+	_, session, err := m.authorize(ctx, chatID, sessionID, "")
 	if err != nil {
-		if errors.Is(err, repositories.ErrChatNotFound) {
-			return fmt.Errorf("user is not in chat or chat ID is wrong: %v", err)
+		return err
+	}
+
+	message, err := m.findChatMessage(ctx, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	if message.SenderID != session.UserID {
+		if _, _, err = m.authorize(ctx, chatID, sessionID, domain.PermDeleteOthersMessages); err != nil {
+			return err
 		}
+	}
+
+	return m.MessageService.DeleteMessage(ctx, chatID, session.UserID, messageID)
+}
+
+func (m *Messaging) PinMessage(ctx context.Context, chatID, sessionID, messageID domain.ID) error {
+	if _, _, err := m.authorize(ctx, chatID, sessionID, domain.PermPinMessages); err != nil {
+		return err
+	}
+	if _, err := m.findChatMessage(ctx, chatID, messageID); err != nil {
+		return err
+	}
+	return m.ChatService.PinMessage(ctx, chatID, messageID)
+}
+
+func (m *Messaging) UnpinMessage(ctx context.Context, chatID, sessionID, messageID domain.ID) error {
+	if _, _, err := m.authorize(ctx, chatID, sessionID, domain.PermPinMessages); err != nil {
 		return err
 	}
+	return m.ChatService.UnpinMessage(ctx, chatID, messageID)
+}
+
+func (m *Messaging) findChatMessage(ctx context.Context, chatID, messageID domain.ID) (domain.Message, error) {
+	message, err := m.MessageService.FindMessage(ctx, messageID)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	if message.ChatID != chatID {
+		return domain.Message{}, domain.NotFound("message %v does not belong to this chat", messageID)
+	}
+	return message, nil
+}
 
-	if isUserInChat == "" {
-		return fmt.Errorf("user role in chat is missing")
+// ViewMessages records that the caller has seen the given channel posts,
+// which must all belong to the channel.
+func (m *Messaging) ViewMessages(ctx context.Context, chatID, sessionID domain.ID, messageIDs []domain.ID) error {
+	chat, session, err := m.authorize(ctx, chatID, sessionID, "")
+	if err != nil {
+		return err
+	}
+	if chat.ChatType != domain.Channel {
+		return domain.InvalidArgument("views are only tracked for channel posts")
 	}
 
-	if isUserInChat == "owner" || isUserInChat == "admin" || isUserInChat == "user" {
-		if err = m.SessionService.DeleteSession(sessionID); err != nil {
+	for _, messageID := range messageIDs {
+		if _, err = m.findChatMessage(ctx, chatID, messageID); err != nil {
+			return err
+		}
+		if err = m.MessageService.AddView(ctx, messageID, session.UserID); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// SearchMessages searches the chats the caller currently belongs to. Chats
+// are re-checked against their member lists so that results from chats the
+// user has left never leak through a stale session, and each chat's history
+// visibility setting limits how far back results go.
+func (m *Messaging) SearchMessages(ctx context.Context, sessionID domain.ID, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	session, err := m.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.ID
+	if query.ChatID != "" {
+		candidates = append(candidates, query.ChatID)
+	} else {
+		for chatID := range session.ChatIDAndName {
+			candidates = append(candidates, domain.ID(chatID))
+		}
+	}
+
+	scope := make(services.SearchScope)
+	for _, chatID := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chat, err := m.ChatService.FindChat(ctx, chatID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrChatNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if !chat.IsMember(session.UserID) {
+			continue
+		}
+		cutoff, err := m.ChatService.HistoryCutoff(ctx, chat, session.UserID)
+		if err != nil {
+			return nil, err
+		}
+		scope[chatID] = cutoff
+	}
+	if query.ChatID != "" && len(scope) == 0 {
+		return nil, domain.NotFound("user is not in this chat or chat doesn't exist: %v", query.ChatID)
+	}
+
+	user, err := m.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	query.ExcludeSenders = user.Blocked
+
+	return m.MessageService.SearchMessages(ctx, query, scope)
+}
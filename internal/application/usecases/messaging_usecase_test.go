@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/core/services"
+	"testing"
+	"time"
+)
+
+func TestSlowMode(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	chatID := f.newChat(domain.Group, "slow", ownerSession, member.ID)
+
+	err := f.chatManagement.SetSlowMode(f.ctx, chatID, memberSession, time.Hour)
+	wantCode(t, err, domain.CodeForbidden)
+	if err = f.chatManagement.SetSlowMode(f.ctx, chatID, ownerSession, time.Hour); err != nil {
+		t.Fatalf("SetSlowMode: %v", err)
+	}
+
+	if err = f.messaging.SendMessage(f.ctx, chatID, memberSession, "first"); err != nil {
+		t.Fatalf("first message: %v", err)
+	}
+	err = f.messaging.SendMessage(f.ctx, chatID, memberSession, "second")
+	wantIs(t, err, repositories.ErrSlowMode)
+	wantCode(t, err, domain.CodeRateLimited)
+	err = f.messaging.SendMedia(f.ctx, chatID, memberSession, "", []domain.Attachment{{ID: "a", Name: "cat.png", ContentType: "image/png", Size: 1}})
+	wantIs(t, err, repositories.ErrSlowMode)
+
+	// The owner and admins are exempt.
+	for _, text := range []string{"one", "two"} {
+		if err = f.messaging.SendMessage(f.ctx, chatID, ownerSession, text); err != nil {
+			t.Fatalf("owner message %q: %v", text, err)
+		}
+	}
+}
+
+func TestSlowModeRejectionKeepsRateLimitTokens(t *testing.T) {
+	f := newFixture(t)
+	f.rateLimiter.Limits = services.RateLimits{
+		domain.OpSendMessage: {domain.TierStandard: {Burst: 2, RefillEvery: time.Hour}},
+	}
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	slow := f.newChat(domain.Group, "slow", ownerSession, member.ID)
+	fast := f.newChat(domain.Group, "fast", ownerSession, member.ID)
+	if err := f.chatManagement.SetSlowMode(f.ctx, slow, ownerSession, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.messaging.SendMessage(f.ctx, slow, memberSession, "first"); err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		err := f.messaging.SendMessage(f.ctx, slow, memberSession, "again")
+		wantIs(t, err, repositories.ErrSlowMode)
+	}
+	if err := f.messaging.SendMessage(f.ctx, fast, memberSession, "elsewhere"); err != nil {
+		t.Fatalf("slow mode rejections used up rate limit tokens: %v", err)
+	}
+	err := f.messaging.SendMessage(f.ctx, fast, memberSession, "one too many")
+	wantIs(t, err, repositories.ErrRateLimited)
+}
+
+func TestMutedMemberCannotPost(t *testing.T) {
+	f := newFixture(t)
+	_, ownerSession := f.register("owner")
+	member, memberSession := f.register("member")
+	chatID := f.newChat(domain.Group, "team", ownerSession, member.ID)
+
+	if err := f.chatManagement.MuteUser(f.ctx, chatID, ownerSession, member.ID, "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	err := f.messaging.SendMessage(f.ctx, chatID, memberSession, "hello")
+	wantCode(t, err, domain.CodeForbidden)
+
+	if err = f.chatManagement.UnmuteUser(f.ctx, chatID, ownerSession, member.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.messaging.SendMessage(f.ctx, chatID, memberSession, "hello"); err != nil {
+		t.Fatalf("SendMessage after unmute: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+// KickUser removes a member from the chat. Unlike a ban, they may rejoin.
+func (cm *ChatManagement) KickUser(ctx context.Context, chatID, sessionID, userID domain.ID, reason string) error {
+	chat, session, err := cm.authorizeModeration(ctx, chatID, sessionID, userID, domain.PermRemoveMembers)
+	if err != nil {
+		return err
+	}
+	if !chat.IsMember(userID) {
+		return domain.InvalidArgument("user %s is not a member of this chat", userID)
+	}
+
+	if err = cm.removeMembers(ctx, chat, []domain.ID{userID}); err != nil {
+		return err
+	}
+	return cm.recordModeration(ctx, domain.EventUserKicked, chatID, session.UserID, userID, reason, nil)
+}
+
+// BanUser removes the user from the chat and keeps them out, including via
+// invite links, until the ban expires. A zero duration bans permanently.
+func (cm *ChatManagement) BanUser(ctx context.Context, chatID, sessionID, userID domain.ID, reason string, duration time.Duration) error {
+	chat, session, err := cm.authorizeModeration(ctx, chatID, sessionID, userID, domain.PermRestrictMembers)
+	if err != nil {
+		return err
+	}
+
+	ban, err := cm.ModerationService.Restrict(ctx, domain.Restriction{
+		ChatID:   chatID,
+		UserID:   userID,
+		IssuedBy: session.UserID,
+		Type:     domain.Banned,
+		Reason:   reason,
+	}, duration)
+	if err != nil {
+		return err
+	}
+
+	if chat.IsMember(userID) {
+		if err = cm.removeMembers(ctx, chat, []domain.ID{userID}); err != nil {
+			return err
+		}
+	}
+	return cm.recordModeration(ctx, domain.EventUserBanned, chatID, session.UserID, userID, reason, ban.ExpiresTime)
+}
+
+func (cm *ChatManagement) UnbanUser(ctx context.Context, chatID, sessionID, userID domain.ID) error {
+	_, session, err := cm.authorizeModeration(ctx, chatID, sessionID, userID, domain.PermRestrictMembers)
+	if err != nil {
+		return err
+	}
+
+	if err = cm.ModerationService.Lift(ctx, chatID, userID, domain.Banned); err != nil {
+		return err
+	}
+	return cm.recordModeration(ctx, domain.EventUserUnbanned, chatID, session.UserID, userID, "", nil)
+}
+
+// MuteUser keeps the member in the chat but rejects their messages until
+// the mute expires. A zero duration mutes permanently.
+func (cm *ChatManagement) MuteUser(ctx context.Context, chatID, sessionID, userID domain.ID, reason string, duration time.Duration) error {
+	chat, session, err := cm.authorizeModeration(ctx, chatID, sessionID, userID, domain.PermRestrictMembers)
+	if err != nil {
+		return err
+	}
+	if !chat.IsMember(userID) {
+		return domain.InvalidArgument("user %s is not a member of this chat", userID)
+	}
+
+	mute, err := cm.ModerationService.Restrict(ctx, domain.Restriction{
+		ChatID:   chatID,
+		UserID:   userID,
+		IssuedBy: session.UserID,
+		Type:     domain.Muted,
+		Reason:   reason,
+	}, duration)
+	if err != nil {
+		return err
+	}
+	return cm.recordModeration(ctx, domain.EventUserMuted, chatID, session.UserID, userID, reason, mute.ExpiresTime)
+}
+
+func (cm *ChatManagement) UnmuteUser(ctx context.Context, chatID, sessionID, userID domain.ID) error {
+	_, session, err := cm.authorizeModeration(ctx, chatID, sessionID, userID, domain.PermRestrictMembers)
+	if err != nil {
+		return err
+	}
+
+	if err = cm.ModerationService.Lift(ctx, chatID, userID, domain.Muted); err != nil {
+		return err
+	}
+	return cm.recordModeration(ctx, domain.EventUserUnmuted, chatID, session.UserID, userID, "", nil)
+}
+
+// ListRestrictedMembers returns the chat's bans and mutes that are still in force.
+func (cm *ChatManagement) ListRestrictedMembers(ctx context.Context, chatID, sessionID domain.ID) ([]domain.Restriction, error) {
+	if _, _, err := cm.authorize(ctx, chatID, sessionID, domain.PermRestrictMembers); err != nil {
+		return nil, err
+	}
+	return cm.ModerationService.ListActive(ctx, chatID)
+}
+
+// authorizeModeration checks the permission and that the target ranks
+// below the caller. Targets that already left the chat count as normal
+// members so they can still be banned and unbanned.
+func (cm *ChatManagement) authorizeModeration(ctx context.Context, chatID, sessionID, userID domain.ID, permission domain.Permission) (domain.Chat, domain.Session, error) {
+	chat, session, err := cm.authorize(ctx, chatID, sessionID, permission)
+	if err != nil {
+		return domain.Chat{}, domain.Session{}, err
+	}
+	if userID == session.UserID {
+		return domain.Chat{}, domain.Session{}, domain.InvalidArgument("you cannot moderate yourself")
+	}
+
+	targetRole := chat.RoleOf(userID)
+	if targetRole == "" {
+		targetRole = domain.Normal
+	}
+	if err = canManage(chat.RoleOf(session.UserID), targetRole); err != nil {
+		return domain.Chat{}, domain.Session{}, err
+	}
+	return chat, session, nil
+}
+
+func (cm *ChatManagement) recordModeration(ctx context.Context, eventType domain.EventType, chatID, actorID, targetID domain.ID, reason string, expires *time.Time) error {
+	details := map[string]string{}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	if expires != nil {
+		details["expires"] = expires.Format(time.RFC3339)
+	}
+	return cm.AuditService.Record(ctx, domain.Event{
+		Type:     eventType,
+		ChatID:   chatID,
+		ActorID:  actorID,
+		TargetID: targetID,
+		Details:  details,
+	})
+}
@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// moderationChat is a group with an owner, two admins and a member.
+type moderationChat struct {
+	id                                     domain.ID
+	owner, admin, otherAdmin, member       domain.User
+	ownerSession, adminSession, memberSess domain.ID
+}
+
+func newModerationChat(f *fixture) moderationChat {
+	f.t.Helper()
+	var c moderationChat
+	c.owner, c.ownerSession = f.register("owner")
+	c.admin, c.adminSession = f.register("admin")
+	c.otherAdmin, _ = f.register("other_admin")
+	c.member, c.memberSess = f.register("member")
+	c.id = f.newChat(domain.Group, "moderated", c.ownerSession, c.admin.ID, c.otherAdmin.ID, c.member.ID)
+	if err := f.chatManagement.SetAdmin(f.ctx, c.id, c.ownerSession, []domain.ID{c.admin.ID, c.otherAdmin.ID}); err != nil {
+		f.t.Fatalf("SetAdmin: %v", err)
+	}
+	return c
+}
+
+func TestModerationHierarchy(t *testing.T) {
+	f := newFixture(t)
+	c := newModerationChat(f)
+
+	tests := []struct {
+		name     string
+		moderate func() error
+		wantCode domain.ErrorCode
+	}{
+		{"admin kicks the owner", func() error {
+			return f.chatManagement.KickUser(f.ctx, c.id, c.adminSession, c.owner.ID, "")
+		}, domain.CodeForbidden},
+		{"admin bans another admin", func() error {
+			return f.chatManagement.BanUser(f.ctx, c.id, c.adminSession, c.otherAdmin.ID, "", 0)
+		}, domain.CodeForbidden},
+		{"admin mutes another admin", func() error {
+			return f.chatManagement.MuteUser(f.ctx, c.id, c.adminSession, c.otherAdmin.ID, "", time.Hour)
+		}, domain.CodeForbidden},
+		{"member mutes an admin", func() error {
+			return f.chatManagement.MuteUser(f.ctx, c.id, c.memberSess, c.admin.ID, "", time.Hour)
+		}, domain.CodeForbidden},
+		{"admin mutes themself", func() error {
+			return f.chatManagement.MuteUser(f.ctx, c.id, c.adminSession, c.admin.ID, "", time.Hour)
+		}, domain.CodeInvalidArgument},
+		{"admin mutes a member", func() error {
+			return f.chatManagement.MuteUser(f.ctx, c.id, c.adminSession, c.member.ID, "spam", time.Hour)
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantCode(t, tt.moderate(), tt.wantCode)
+		})
+	}
+}
+
+func TestLiftRestrictionRespectsHierarchy(t *testing.T) {
+	f := newFixture(t)
+	c := newModerationChat(f)
+
+	if err := f.chatManagement.MuteUser(f.ctx, c.id, c.ownerSession, c.otherAdmin.ID, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	err := f.chatManagement.UnmuteUser(f.ctx, c.id, c.adminSession, c.otherAdmin.ID)
+	wantCode(t, err, domain.CodeForbidden)
+	if _, active, _ := f.moderationService.ActiveRestriction(f.ctx, c.id, c.otherAdmin.ID, domain.Muted); !active {
+		t.Fatal("an admin lifted another admin's mute")
+	}
+	if err = f.chatManagement.UnmuteUser(f.ctx, c.id, c.ownerSession, c.otherAdmin.ID); err != nil {
+		t.Fatalf("owner UnmuteUser: %v", err)
+	}
+
+	if err = f.chatManagement.BanUser(f.ctx, c.id, c.adminSession, c.member.ID, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	err = f.chatManagement.UnbanUser(f.ctx, c.id, c.adminSession, c.owner.ID)
+	wantCode(t, err, domain.CodeForbidden)
+	if err = f.chatManagement.UnbanUser(f.ctx, c.id, c.adminSession, c.member.ID); err != nil {
+		t.Fatalf("admin UnbanUser: %v", err)
+	}
+}
+
+func TestBanKeepsUserOut(t *testing.T) {
+	f := newFixture(t)
+	c := newModerationChat(f)
+	invite, err := f.chatManagement.CreateInvite(f.ctx, c.id, c.ownerSession, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = f.chatManagement.BanUser(f.ctx, c.id, c.adminSession, c.member.ID, "spam", time.Hour); err != nil {
+		t.Fatalf("BanUser: %v", err)
+	}
+	if f.chat(c.id).IsMember(c.member.ID) {
+		t.Error("banned user is still a member")
+	}
+	if _, ok := f.session(c.memberSess).ChatIDAndName[string(c.id)]; ok {
+		t.Error("chat is still listed in the banned user's session")
+	}
+	_, err = f.chatManagement.JoinByInvite(f.ctx, invite.Code, c.memberSess)
+	wantCode(t, err, domain.CodeForbidden)
+
+	if err = f.chatManagement.UnbanUser(f.ctx, c.id, c.adminSession, c.member.ID); err != nil {
+		t.Fatalf("UnbanUser: %v", err)
+	}
+	if joined, err := f.chatManagement.JoinByInvite(f.ctx, invite.Code, c.memberSess); err != nil || !joined {
+		t.Fatalf("JoinByInvite after unban = %v, %v", joined, err)
+	}
+
+	err = f.chatManagement.UnbanUser(f.ctx, c.id, c.adminSession, c.member.ID)
+	wantIs(t, err, repositories.ErrRestrictionNotFound)
+
+	var got []domain.EventType
+	for _, event := range f.audit.Published() {
+		if event.TargetID == c.member.ID {
+			got = append(got, event.Type)
+		}
+	}
+	want := []domain.EventType{domain.EventUserBanned, domain.EventUserUnbanned}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/services"
+	"context"
+)
+
+// allowUser applies the rate limit of the user's tier for the operation.
+func allowUser(ctx context.Context, limiter *services.RateLimiter, userService *services.UserService, operation domain.Operation, userID domain.ID) error {
+	user, err := userService.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return limiter.AllowUser(ctx, operation, userID, user.Tier)
+}
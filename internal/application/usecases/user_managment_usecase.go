@@ -2,75 +2,392 @@ package usecases
 
 import (
 	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
 	"chat-app/internal/core/services"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type UserManagement struct {
-	UserService    *services.UserService
-	ChatService    *services.ChatService
-	SessionService *services.SessionService
-	Sessions       *[]domain.Session
+	UserService      *services.UserService
+	ChatService      *services.ChatService
+	SessionService   *services.SessionService
+	TwoFactorService *services.TwoFactorService
+	LoginGuard       *services.LoginGuardService
+	RateLimiter      *services.RateLimiter
+	Mailer           repositories.Mailer
 }
 
-//type UserRepository interface {
-//	Register(user domain.User) (userID domain.ID, err error)
-//	Login(username, password string) (isAuthorized bool, err error)
-//	GetChatIDList(userID domain.ID) (chatIDList []string, err error)
-//	GetUserInfo(userID domain.ID) (user domain.User, err error)
-//}
+// sessionTTL is how long a session lasts before the user has to log in
+// again.
+const sessionTTL = 30 * 24 * time.Hour
 
-func NewUserManagement(userService *services.UserService, sessionService *services.SessionService, sessions *[]domain.Session) *UserManagement {
+func NewUserManagement(userService *services.UserService, chatService *services.ChatService, sessionService *services.SessionService, twoFactorService *services.TwoFactorService, loginGuard *services.LoginGuardService, rateLimiter *services.RateLimiter, mailer repositories.Mailer) *UserManagement {
 	return &UserManagement{
-		UserService:    userService,
-		SessionService: sessionService,
-		Sessions:       sessions,
+		UserService:      userService,
+		ChatService:      chatService,
+		SessionService:   sessionService,
+		TwoFactorService: twoFactorService,
+		LoginGuard:       loginGuard,
+		RateLimiter:      rateLimiter,
+		Mailer:           mailer,
 	}
 }
 
-func (um *UserManagement) Register(user domain.User) (domain.Session, error) {
-	userID, err := um.UserService.Register(user)
+// Register creates the account and logs the new user in.
+func (um *UserManagement) Register(ctx context.Context, user domain.User) (domain.Session, error) {
+	userID, err := um.UserService.Register(ctx, user)
 	if err != nil {
 		return domain.Session{}, err
 	}
-	sessionID := domain.ID(uuid.New().String())
-	session := domain.Session{
-		SessionID: sessionID,
-		UserID:    userID,
+	return um.newSession(ctx, userID)
+}
+
+// Login checks the password. Users with two-factor authentication, or who
+// must enrol because it is required, get a challenge to answer with
+// CompleteLogin instead of a session. Repeated failures from the same
+// username or ip are slowed down and eventually locked out.
+func (um *UserManagement) Login(ctx context.Context, username, password, ip string) (domain.LoginResult, error) {
+	if err := services.ValidateCredentials(username, password); err != nil {
+		return domain.LoginResult{}, err
+	}
+	if err := um.RateLimiter.AllowIP(ctx, domain.OpLogin, ip, username); err != nil {
+		return domain.LoginResult{}, err
+	}
+	if err := um.LoginGuard.Check(ctx, username, ip); err != nil {
+		return domain.LoginResult{}, err
 	}
-	*um.Sessions = append(*um.Sessions, session)
 
-	return session, nil
+	userID, err := um.UserService.Login(ctx, username, password)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWrongLoginInfo) {
+			if guardErr := um.recordLoginFailure(ctx, username, ip); guardErr != nil {
+				return domain.LoginResult{}, guardErr
+			}
+		}
+		return domain.LoginResult{}, err
+	}
+
+	enabled, err := um.TwoFactorService.IsEnabled(ctx, userID)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	if enabled || um.TwoFactorService.Required {
+		var provisioningURI string
+		if !enabled {
+			user, err := um.UserService.GetUserInfo(ctx, userID)
+			if err != nil {
+				return domain.LoginResult{}, err
+			}
+			if provisioningURI, err = um.TwoFactorService.BeginEnrollment(ctx, user); err != nil {
+				return domain.LoginResult{}, err
+			}
+		}
+		challenge, err := um.TwoFactorService.CreateChallenge(ctx, userID, provisioningURI)
+		if err != nil {
+			return domain.LoginResult{}, err
+		}
+		return domain.LoginResult{Challenge: &challenge}, nil
+	}
 
+	if err = um.LoginGuard.RecordSuccess(ctx, username); err != nil {
+		return domain.LoginResult{}, err
+	}
+	session, err := um.newSession(ctx, userID)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	return domain.LoginResult{Session: &session}, nil
 }
-func (um *UserManagement) Login(username string, password string) (domain.Session, error) {
-	userID, err := um.UserService.Login(username, password)
+
+// CompleteLogin answers a login challenge with an authenticator or
+// recovery code and returns the session. Wrong codes count as failed
+// logins for the username and ip, and the username's failures are only
+// cleared once this step succeeds.
+func (um *UserManagement) CompleteLogin(ctx context.Context, challengeID domain.ID, code, ip string) (domain.LoginResult, error) {
+	challenge, err := um.TwoFactorService.FindChallenge(ctx, challengeID)
 	if err != nil {
-		return domain.Session{}, err
+		return domain.LoginResult{}, err
+	}
+	user, err := um.UserService.GetUserInfo(ctx, challenge.UserID)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	if err = um.LoginGuard.Check(ctx, user.Username, ip); err != nil {
+		return domain.LoginResult{}, err
+	}
+
+	userID, recoveryCodes, err := um.TwoFactorService.CompleteChallenge(ctx, challengeID, code)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCode) {
+			if guardErr := um.recordLoginFailure(ctx, user.Username, ip); guardErr != nil {
+				return domain.LoginResult{}, guardErr
+			}
+		}
+		return domain.LoginResult{}, err
+	}
+	if err = um.LoginGuard.RecordSuccess(ctx, user.Username); err != nil {
+		return domain.LoginResult{}, err
 	}
 
-	chatIDList, err := um.UserService.GetChatIDList(userID)
+	session, err := um.newSession(ctx, userID)
+	if err != nil {
+		return domain.LoginResult{}, err
+	}
+	return domain.LoginResult{Session: &session, RecoveryCodes: recoveryCodes}, nil
+}
+
+// recordLoginFailure counts a failed password or code and mails the user
+// if it locked their account.
+func (um *UserManagement) recordLoginFailure(ctx context.Context, username, ip string) error {
+	lockedUntil, err := um.LoginGuard.RecordFailure(ctx, username, ip)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		um.notifyLockout(ctx, username, *lockedUntil)
+	}
+	return nil
+}
+
+// notifyLockout tells the user their account was locked. It is best effort:
+// the lockout stands even if the mail cannot be sent.
+func (um *UserManagement) notifyLockout(ctx context.Context, username string, lockedUntil time.Time) {
+	user, err := um.UserService.FindUser(ctx, username)
+	if err != nil || user.Email == "" {
+		return
+	}
+	_ = um.Mailer.Send(ctx, domain.Mail{
+		To:      user.Email,
+		Subject: "Your account was temporarily locked",
+		Body: fmt.Sprintf("Hi %s,\n\nThere were too many failed attempts to log into your account, so logins are blocked until %s.\nIf this was not you, consider changing your password.\n",
+			user.DisplayName(), lockedUntil.Format(time.RFC1123)),
+	})
+}
+
+// newSession builds the session of a logged in user from their chats and
+// stores it for sessionTTL. It also cancels a pending deletion of the
+// account, which only a complete login may do.
+func (um *UserManagement) newSession(ctx context.Context, userID domain.ID) (domain.Session, error) {
+	if err := um.UserService.RestoreAccount(ctx, userID); err != nil {
+		return domain.Session{}, err
+	}
+	chatIDList, err := um.UserService.GetChatIDList(ctx, userID)
 	if err != nil {
 		return domain.Session{}, err
 	}
 
 	var chatNameList []string
-	var chatNameAndID map[string]string
+	chatIDAndName := make(map[string]string)
 	for _, chatID := range chatIDList {
-		chat, err := um.ChatService.FindChat(domain.ID(chatID))
+		if err := ctx.Err(); err != nil {
+			return domain.Session{}, err
+		}
+		chat, err := um.ChatService.FindChat(ctx, domain.ID(chatID))
 		if err != nil {
+			if errors.Is(err, repositories.ErrChatNotFound) {
+				continue // deleted chats are hidden
+			}
 			return domain.Session{}, err
 		}
-		chatNameAndID[chat.Name] = chatID
-		chatNameList = append(chatNameList, chat.Name)
+		chatName, err := chatNameFor(ctx, um.UserService, chat, userID)
+		if err != nil {
+			return domain.Session{}, err
+		}
+		chatIDAndName[chatID] = chatName
+		chatNameList = append(chatNameList, chatName)
 	}
 	sessionID := domain.ID(uuid.New().String())
 	session := domain.Session{
 		SessionID:     sessionID,
 		UserID:        userID,
-		ChatNameAndID: chatNameAndID,
+		ChatIDAndName: chatIDAndName,
 		ChatNameList:  chatNameList,
 	}
-
+	if err = um.SessionService.CreateSession(ctx, session, sessionTTL); err != nil {
+		return domain.Session{}, err
+	}
 	return session, nil
 }
+
+// GetUserInfo returns another user's public profile.
+func (um *UserManagement) GetUserInfo(ctx context.Context, userID, sessionID domain.ID) (domain.PublicProfile, error) {
+	if _, err := um.SessionService.GetSession(ctx, sessionID); err != nil {
+		return domain.PublicProfile{}, err
+	}
+	return um.UserService.GetPublicProfile(ctx, userID)
+}
+
+// GetProfile returns the caller's own account, without the password.
+func (um *UserManagement) GetProfile(ctx context.Context, sessionID domain.ID) (domain.User, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return um.UserService.GetUserInfo(ctx, session.UserID)
+}
+
+// UpdateProfile changes the caller's profile. If their display name changes,
+// the private chats with them are re-listed under the new name in the other
+// participants' sessions.
+func (um *UserManagement) UpdateProfile(ctx context.Context, sessionID domain.ID, update domain.ProfileUpdate) (domain.User, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	before, err := um.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	user, err := um.UserService.UpdateProfile(ctx, session.UserID, update)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if user.DisplayName() != before.DisplayName() {
+		if err = um.renamePrivateChats(ctx, user); err != nil {
+			return domain.User{}, err
+		}
+	}
+	return user, nil
+}
+
+// renamePrivateChats re-lists the user's private chats under their current
+// display name for the other participants who have a session.
+func (um *UserManagement) renamePrivateChats(ctx context.Context, user domain.User) error {
+	chatIDList, err := um.UserService.GetChatIDList(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, chatID := range chatIDList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chat, err := um.ChatService.FindChat(ctx, domain.ID(chatID))
+		if err != nil {
+			if errors.Is(err, repositories.ErrChatNotFound) {
+				continue
+			}
+			return err
+		}
+		if chat.ChatType != domain.Private {
+			continue
+		}
+		for _, member := range chat.Members {
+			if member == user.ID {
+				continue
+			}
+			session, err := um.SessionService.GetSessionByUserID(ctx, member)
+			if err != nil {
+				continue
+			}
+			if err = um.SessionService.AddChatToSession(ctx, session.SessionID, chat.ID, user.DisplayName(), domain.Normal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (um *UserManagement) ChangePassword(ctx context.Context, sessionID domain.ID, currentPassword, newPassword string) error {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return um.UserService.ChangePassword(ctx, session.UserID, currentPassword, newPassword)
+}
+
+// BlockUser blocks another user: they can no longer start a private chat
+// with the caller or add them to groups, and their messages are hidden
+// from the caller in shared chats.
+func (um *UserManagement) BlockUser(ctx context.Context, sessionID, userID domain.ID) error {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return um.UserService.BlockUser(ctx, session.UserID, userID)
+}
+
+func (um *UserManagement) UnblockUser(ctx context.Context, sessionID, userID domain.ID) error {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return um.UserService.UnblockUser(ctx, session.UserID, userID)
+}
+
+func (um *UserManagement) ListBlockedUsers(ctx context.Context, sessionID domain.ID) ([]domain.PublicProfile, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return um.UserService.ListBlocked(ctx, session.UserID)
+}
+
+// EnableTwoFactor starts enrolment and returns the provisioning URI to show
+// as a QR code; ConfirmTwoFactor finishes it.
+func (um *UserManagement) EnableTwoFactor(ctx context.Context, sessionID domain.ID) (string, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	user, err := um.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return "", err
+	}
+	return um.TwoFactorService.BeginEnrollment(ctx, user)
+}
+
+// ConfirmTwoFactor enables two-factor authentication with a first code from
+// the authenticator and returns the one-time recovery codes.
+func (um *UserManagement) ConfirmTwoFactor(ctx context.Context, sessionID domain.ID, code string) ([]string, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return um.TwoFactorService.ConfirmEnrollment(ctx, session.UserID, code)
+}
+
+func (um *UserManagement) DisableTwoFactor(ctx context.Context, sessionID domain.ID, code string) error {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return um.TwoFactorService.Disable(ctx, session.UserID, code)
+}
+
+func (um *UserManagement) RegenerateRecoveryCodes(ctx context.Context, sessionID domain.ID, code string) ([]string, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return um.TwoFactorService.RegenerateRecoveryCodes(ctx, session.UserID, code)
+}
+
+// DeleteAccount deletes the caller's account and ends all of their
+// sessions. Logging in again within the grace period restores the account.
+func (um *UserManagement) DeleteAccount(ctx context.Context, sessionID domain.ID, password string) error {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err = um.UserService.DeleteAccount(ctx, session.UserID, password); err != nil {
+		return err
+	}
+	return um.SessionService.DeleteUserSessions(ctx, session.UserID)
+}
+
+func (um *UserManagement) SearchUsers(ctx context.Context, sessionID domain.ID, query domain.UserSearchQuery) (domain.UserSearchPage, error) {
+	session, err := um.SessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		return domain.UserSearchPage{}, err
+	}
+	searcher, err := um.UserService.GetUserInfo(ctx, session.UserID)
+	if err != nil {
+		return domain.UserSearchPage{}, err
+	}
+	return um.UserService.SearchUsers(ctx, searcher, query)
+}
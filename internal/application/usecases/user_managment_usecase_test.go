@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"reflect"
+	"testing"
+)
+
+func TestDeleteAccountRevokesAllSessions(t *testing.T) {
+	f := newFixture(t)
+	user, phone := f.register("leaving")
+	laptop := f.login(user.ID)
+	_, otherSession := f.register("staying")
+
+	err := f.userManagement.DeleteAccount(f.ctx, phone, "wrong password")
+	wantCode(t, err, domain.CodeUnauthenticated)
+	f.session(laptop)
+
+	if err = f.userManagement.DeleteAccount(f.ctx, phone, testPassword); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	f.wantRevoked(phone, laptop)
+	f.session(otherSession)
+
+	stored, err := f.users.GetUserInfo(f.ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.DeletedTime == nil {
+		t.Error("account is not marked as deleted")
+	}
+}
+
+func TestRegisterAndLoginStoreSessions(t *testing.T) {
+	f := newFixture(t)
+	user, registered := f.register("newcomer")
+	if got := f.session(registered); got.UserID != user.ID {
+		t.Fatalf("registration session belongs to %s, want %s", got.UserID, user.ID)
+	}
+
+	_, ownerSession := f.register("owner")
+	chatID := f.newChat(domain.Group, "team", ownerSession, user.ID)
+
+	_, err := f.userManagement.Login(f.ctx, user.Username, "wrong password", "192.0.2.1")
+	wantCode(t, err, domain.CodeUnauthenticated)
+
+	result, err := f.userManagement.Login(f.ctx, user.Username, testPassword, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if result.Session == nil {
+		t.Fatalf("Login returned %+v, want a session", result)
+	}
+	stored := f.session(result.Session.SessionID)
+	if stored.UserID != user.ID || stored.ChatIDAndName[string(chatID)] != "team" {
+		t.Errorf("stored session = %+v, want the user's session listing team", stored)
+	}
+	f.session(registered)
+}
+
+func TestLoginRestoresDeletedAccount(t *testing.T) {
+	f := newFixture(t)
+	user, sessionID := f.register("undecided")
+	if err := f.userManagement.DeleteAccount(f.ctx, sessionID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := f.userManagement.Login(f.ctx, user.Username, testPassword, "192.0.2.1")
+	if err != nil || result.Session == nil {
+		t.Fatalf("Login = %+v, %v, want a session", result, err)
+	}
+	stored, err := f.users.GetUserInfo(f.ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.DeletedTime != nil {
+		t.Error("logging in did not cancel the deletion")
+	}
+}
+
+func TestBlockUser(t *testing.T) {
+	f := newFixture(t)
+	alice, aliceSession := f.register("alice")
+	bob, bobSession := f.register("bob")
+	_, ownerSession := f.register("owner")
+	chatID := f.newChat(domain.Group, "team", ownerSession, alice.ID, bob.ID)
+
+	wantCode(t, f.userManagement.BlockUser(f.ctx, aliceSession, alice.ID), domain.CodeInvalidArgument)
+	wantCode(t, f.userManagement.BlockUser(f.ctx, aliceSession, "nobody"), domain.CodeNotFound)
+	if err := f.userManagement.BlockUser(f.ctx, aliceSession, bob.ID); err != nil {
+		t.Fatalf("BlockUser: %v", err)
+	}
+	blocked, err := f.userManagement.ListBlockedUsers(f.ctx, aliceSession)
+	if err != nil {
+		t.Fatalf("ListBlockedUsers: %v", err)
+	}
+	if want := []domain.PublicProfile{bob.PublicProfile()}; !reflect.DeepEqual(blocked, want) {
+		t.Errorf("blocked = %v, want %v", blocked, want)
+	}
+
+	// Bob can neither start a private chat with alice nor add her to his group.
+	_, err = f.chatManagement.CreatePrivateChat(f.ctx, alice.ID, bobSession)
+	wantIs(t, err, repositories.ErrUserBlocked)
+	groupID := f.newChat(domain.Group, "bob's", bobSession)
+	err = f.chatManagement.AddUser(f.ctx, groupID, bobSession, []domain.ID{alice.ID})
+	wantIs(t, err, repositories.ErrUserBlocked)
+
+	// In the chat they share, alice no longer sees bob's messages.
+	for _, session := range []domain.ID{ownerSession, bobSession} {
+		if err = f.messaging.SendMessage(f.ctx, chatID, session, "hello team"); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+	senders := func() (read, found []domain.ID) {
+		t.Helper()
+		messages, err := f.chatManagement.GetMessages(f.ctx, "team", aliceSession)
+		if err != nil {
+			t.Fatalf("GetMessages: %v", err)
+		}
+		for _, message := range messages {
+			read = append(read, message.SenderID)
+		}
+		results, err := f.messaging.SearchMessages(f.ctx, aliceSession, domain.SearchQuery{Text: "hello", ChatID: chatID})
+		if err != nil {
+			t.Fatalf("SearchMessages: %v", err)
+		}
+		for _, result := range results {
+			found = append(found, result.Message.SenderID)
+		}
+		return read, found
+	}
+	owner := f.session(ownerSession).UserID
+	if read, found := senders(); !reflect.DeepEqual(read, []domain.ID{owner}) || !reflect.DeepEqual(found, []domain.ID{owner}) {
+		t.Errorf("alice reads messages from %v and finds them from %v, want only the owner's", read, found)
+	}
+
+	if err = f.userManagement.UnblockUser(f.ctx, aliceSession, bob.ID); err != nil {
+		t.Fatalf("UnblockUser: %v", err)
+	}
+	if read, found := senders(); len(read) != 2 || len(found) != 2 {
+		t.Errorf("after unblocking alice reads messages from %v and finds them from %v, want both", read, found)
+	}
+	if err = f.chatManagement.AddUser(f.ctx, groupID, bobSession, []domain.ID{alice.ID}); err != nil {
+		t.Errorf("AddUser after unblocking: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package domain
+
+type Attachment struct {
+	ID          ID
+	Name        string
+	ContentType string
+	Size        int64
+	StorageKey  string // where the blob lives in the file store
+}
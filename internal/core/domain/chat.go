@@ -8,13 +8,92 @@ const (
 	Normal = "Normal"
 )
 
+// Admins and Members are kept in the order users were promoted or joined.
 type Chat struct {
 	ID          ID
 	Name        string
 	Owner       ID
 	Admins      []ID
 	Members     []ID
+	JoinedTimes map[ID]time.Time
 	CreatedTime *time.Time
 	DeletedTime *time.Time
 	ChatType    ChatType
+	Public      bool // channels only: anyone can join without an invitation
+	Description string
+	Topic       string
+	Avatar      *Attachment
+	Settings    ChatSettings
+	Permissions ChatPermissions
+	Pinned      []ID // pinned message IDs
+}
+
+type ChatSettings struct {
+	History      HistoryVisibility
+	HistoryLastN int           // used with HistoryLastN
+	JoinApproval bool          // joins via invite link wait for an admin's approval
+	SlowMode     time.Duration // minimum gap between a member's messages; 0 disables
+}
+
+// ChatUpdate changes a chat's profile and settings. Nil fields are left as
+// they are. MembersCanAdd is stored as the Normal role's add_members
+// permission rather than as a separate setting.
+type ChatUpdate struct {
+	Name          *string
+	Description   *string
+	Topic         *string
+	Avatar        *Attachment
+	RemoveAvatar  bool
+	History       *HistoryVisibility
+	HistoryLastN  *int
+	JoinApproval  *bool
+	SlowMode      *time.Duration
+	MembersCanAdd *bool
+}
+
+// RoleOf returns the user's role in the chat, or "" if they are not a member.
+// Both participants of a private chat are Normal.
+func (c Chat) RoleOf(userID ID) string {
+	if c.ChatType == Private {
+		for _, member := range c.Members {
+			if member == userID {
+				return Normal
+			}
+		}
+		return ""
+	}
+	if c.Owner == userID {
+		return Owner
+	}
+	for _, admin := range c.Admins {
+		if admin == userID {
+			return Admin
+		}
+	}
+	for _, member := range c.Members {
+		if member == userID {
+			return Normal
+		}
+	}
+	return ""
+}
+
+// Successor picks who inherits the chat when the owner leaves: the oldest
+// admin, then the oldest member. ok is false if nobody else is left.
+func (c Chat) Successor() (userID ID, ok bool) {
+	for _, admin := range c.Admins {
+		if admin != c.Owner {
+			return admin, true
+		}
+	}
+	for _, member := range c.Members {
+		if member != c.Owner {
+			return member, true
+		}
+	}
+	return "", false
+}
+
+func (c Chat) IsMember(userID ID) bool {
+	return c.RoleOf(userID) != ""
 }
@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestSuccessor(t *testing.T) {
+	tests := []struct {
+		name   string
+		chat   Chat
+		want   ID
+		wantOK bool
+	}{
+		{"oldest admin first", Chat{Owner: "owner", Admins: []ID{"late admin", "admin"}, Members: []ID{"owner", "member", "admin", "late admin"}}, "late admin", true},
+		{"owner listed as admin is skipped", Chat{Owner: "owner", Admins: []ID{"owner", "admin"}, Members: []ID{"owner", "admin"}}, "admin", true},
+		{"then oldest member", Chat{Owner: "owner", Members: []ID{"owner", "first", "second"}}, "first", true},
+		{"nobody left", Chat{Owner: "owner", Members: []ID{"owner"}}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.chat.Successor()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Successor() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
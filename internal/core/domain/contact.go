@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type Contact struct {
+	Profile PublicProfile
+	Online  bool
+}
+
+// ContactRequest is a pending request from one user to appear in another's
+// contacts. Accepted or declined requests are deleted.
+type ContactRequest struct {
+	ID          ID
+	FromUserID  ID
+	ToUserID    ID
+	CreatedTime *time.Time
+}
@@ -0,0 +1,27 @@
+package domain
+
+// Discoverability controls who can find a user in the directory search.
+// The zero value behaves like DiscoverableByEveryone.
+type Discoverability int
+
+const (
+	DiscoverableByEveryone Discoverability = iota + 1
+	DiscoverableByContacts
+	DiscoverableByEmailOnly // only an exact email match finds the user
+	NotDiscoverable
+)
+
+type PrivacySettings struct {
+	Discoverability Discoverability
+}
+
+type UserSearchQuery struct {
+	Text   string // username prefix, display name words or an exact email
+	Limit  int
+	Offset int
+}
+
+type UserSearchPage struct {
+	Users      []PublicProfile
+	NextOffset int // 0 when there are no more results
+}
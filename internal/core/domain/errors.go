@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is a stable, transport-independent error category that handlers
+// can map to status codes.
+type ErrorCode string
+
+const (
+	CodeNotFound        ErrorCode = "not_found"
+	CodeForbidden       ErrorCode = "forbidden"
+	CodeConflict        ErrorCode = "conflict"
+	CodeInvalidArgument ErrorCode = "invalid_argument"
+	CodeUnauthenticated ErrorCode = "unauthenticated"
+	CodeRateLimited     ErrorCode = "rate_limited"
+	CodeInternal        ErrorCode = "internal"
+)
+
+// Error is the error type used across services and use cases. Message is
+// safe to show to users; Err is the underlying cause, if any, and is only
+// meant for logs.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+func NewError(code ErrorCode, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) *Error {
+	return NewError(CodeNotFound, format, args...)
+}
+
+func Forbidden(format string, args ...any) *Error {
+	return NewError(CodeForbidden, format, args...)
+}
+
+func Conflict(format string, args ...any) *Error {
+	return NewError(CodeConflict, format, args...)
+}
+
+func InvalidArgument(format string, args ...any) *Error {
+	return NewError(CodeInvalidArgument, format, args...)
+}
+
+func Unauthenticated(format string, args ...any) *Error {
+	return NewError(CodeUnauthenticated, format, args...)
+}
+
+func RateLimited(format string, args ...any) *Error {
+	return NewError(CodeRateLimited, format, args...)
+}
+
+// Wrap adds context to err, keeping its code so that, for example, a
+// repository's not-found error stays not_found. Errors without a code
+// become internal.
+func Wrap(err error, format string, args ...any) *Error {
+	return &Error{Code: CodeOf(err), Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// CodeOf returns the code of the first error in err's chain that has one,
+// or CodeInternal.
+func CodeOf(err error) ErrorCode {
+	var coded interface{ ErrorCode() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return CodeInternal
+}
+
+// MessageOf returns a message about err that is safe to show to users.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Code != CodeInternal {
+		return e.Message
+	}
+	var coded interface{ ErrorCode() ErrorCode }
+	if errors.As(err, &coded) && coded.ErrorCode() != CodeInternal {
+		return coded.(error).Error()
+	}
+	return "internal error"
+}
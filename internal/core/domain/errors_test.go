@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type codedError struct{}
+
+func (codedError) Error() string        { return "slow down" }
+func (codedError) ErrorCode() ErrorCode { return CodeRateLimited }
+
+func TestErrors(t *testing.T) {
+	errMissing := NotFound("user not found")
+	cause := errors.New("disk full")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    ErrorCode
+		wantMessage string
+		wantIs      error
+	}{
+		{"constructor", Forbidden("you cannot %s", "ban"), CodeForbidden, "you cannot ban", nil},
+		{"wrapped sentinel keeps its code", Wrap(errMissing, "failed to find user"), CodeNotFound, "failed to find user", errMissing},
+		{"wrapped twice", Wrap(Wrap(errMissing, "inner"), "outer"), CodeNotFound, "outer", errMissing},
+		{"fmt wrapping keeps the code", fmt.Errorf("lookup: %w", errMissing), CodeNotFound, "user not found", errMissing},
+		{"wrapped plain error is internal", Wrap(cause, "failed to save"), CodeInternal, "internal error", cause},
+		{"plain error is internal", cause, CodeInternal, "internal error", cause},
+		{"other coded errors", fmt.Errorf("send: %w", codedError{}), CodeRateLimited, "slow down", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.wantCode {
+				t.Errorf("CodeOf = %q, want %q", got, tt.wantCode)
+			}
+			if got := MessageOf(tt.err); got != tt.wantMessage {
+				t.Errorf("MessageOf = %q, want %q", got, tt.wantMessage)
+			}
+			if tt.wantIs != nil && !errors.Is(tt.err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.wantIs)
+			}
+		})
+	}
+
+	if got, want := Wrap(cause, "failed to save %s", "chat").Error(), "failed to save chat: disk full"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventRoleChanged  EventType = "role_changed"
+	EventUserKicked   EventType = "user_kicked"
+	EventUserBanned   EventType = "user_banned"
+	EventUserUnbanned EventType = "user_unbanned"
+	EventUserMuted    EventType = "user_muted"
+	EventUserUnmuted  EventType = "user_unmuted"
+)
+
+// Event describes a change made in a chat. Events are published to
+// subscribers and kept as the chat's audit log.
+type Event struct {
+	ID          ID
+	Type        EventType
+	ChatID      ID
+	ActorID     ID
+	TargetID    ID
+	Details     map[string]string
+	CreatedTime *time.Time
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+type ExportStatus int
+
+const (
+	ExportPending ExportStatus = iota + 1
+	ExportReady
+	ExportFailed
+	ExportDownloaded
+)
+
+// DataExport is a user's request for a copy of their personal data. The
+// archive is built in the background and can be downloaded once, with the
+// export's token, until ExpiresTime.
+type DataExport struct {
+	ID             ID
+	UserID         ID
+	Token          string
+	Status         ExportStatus
+	Error          string // why the export failed, if it did
+	CreatedTime    *time.Time
+	ReadyTime      *time.Time
+	ExpiresTime    *time.Time
+	DownloadedTime *time.Time
+}
+
+func (e DataExport) IsDownloadable(now time.Time) bool {
+	if e.Status != ExportReady {
+		return false
+	}
+	return e.ExpiresTime == nil || now.Before(*e.ExpiresTime)
+}
+
+// ExportManifest describes the contents of an export archive. It is written
+// to manifest.json at the root of the archive.
+type ExportManifest struct {
+	ExportID      ID
+	UserID        ID
+	GeneratedTime time.Time
+	Files         []ExportFile
+}
+
+type ExportFile struct {
+	Name        string
+	Description string
+	Entries     int
+}
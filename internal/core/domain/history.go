@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// HistoryVisibility controls how much of a chat's past a member who joins
+// later can read. The zero value behaves like HistoryFull.
+type HistoryVisibility int
+
+const (
+	HistoryFull HistoryVisibility = iota + 1
+	HistorySinceJoined
+	HistoryLastN // the last Settings.HistoryLastN messages before joining, and everything after
+)
+
+// JoinedTime returns when the user joined the chat. Members without a
+// recorded join time are treated as having been there from the start.
+func (c Chat) JoinedTime(userID ID) *time.Time {
+	if joined, ok := c.JoinedTimes[userID]; ok {
+		return &joined
+	}
+	return c.CreatedTime
+}
+
+// HistoryCutoff returns the creation time of the oldest message the user may
+// read, or nil if they may read everything. messages must be the chat's
+// messages, oldest first; they are only consulted for HistoryLastN.
+func (c Chat) HistoryCutoff(userID ID, messages []Message) *time.Time {
+	joined := c.JoinedTime(userID)
+	if joined == nil {
+		return nil
+	}
+
+	switch c.Settings.History {
+	case HistorySinceJoined:
+		return joined
+	case HistoryLastN:
+		firstAfterJoin := len(messages)
+		for i, message := range messages {
+			if message.CreatedTime != nil && !message.CreatedTime.Before(*joined) {
+				firstAfterJoin = i
+				break
+			}
+		}
+		start := max(firstAfterJoin-c.Settings.HistoryLastN, 0)
+		if start < firstAfterJoin && messages[start].CreatedTime != nil {
+			return messages[start].CreatedTime
+		}
+		return joined
+	}
+	return nil
+}
+
+// VisibleMessages filters the chat's messages, oldest first, down to the
+// ones the user may read.
+func (c Chat) VisibleMessages(userID ID, messages []Message) []Message {
+	cutoff := c.HistoryCutoff(userID, messages)
+	if cutoff == nil {
+		return messages
+	}
+
+	var visible []Message
+	for _, message := range messages {
+		if message.CreatedTime != nil && !message.CreatedTime.Before(*cutoff) {
+			visible = append(visible, message)
+		}
+	}
+	return visible
+}
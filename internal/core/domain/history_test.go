@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVisibleMessages(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := start.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	messages := []Message{
+		{ID: "1", CreatedTime: at(1)},
+		{ID: "2", CreatedTime: at(2)},
+		{ID: "3", CreatedTime: at(3)},
+		{ID: "4", CreatedTime: at(5)},
+	}
+	chat := Chat{
+		Owner:       "owner",
+		Members:     []ID{"owner", "late", "founder"},
+		JoinedTimes: map[ID]time.Time{"late": *at(4)},
+		CreatedTime: at(0),
+	}
+
+	tests := []struct {
+		name     string
+		settings ChatSettings
+		userID   ID
+		want     []ID
+	}{
+		{"unset shows everything", ChatSettings{}, "late", []ID{"1", "2", "3", "4"}},
+		{"full", ChatSettings{History: HistoryFull}, "late", []ID{"1", "2", "3", "4"}},
+		{"since joined", ChatSettings{History: HistorySinceJoined}, "late", []ID{"4"}},
+		{"since joined without a join time", ChatSettings{History: HistorySinceJoined}, "founder", []ID{"1", "2", "3", "4"}},
+		{"last two", ChatSettings{History: HistoryLastN, HistoryLastN: 2}, "late", []ID{"2", "3", "4"}},
+		{"last zero", ChatSettings{History: HistoryLastN}, "late", []ID{"4"}},
+		{"last more than there are", ChatSettings{History: HistoryLastN, HistoryLastN: 10}, "late", []ID{"1", "2", "3", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat.Settings = tt.settings
+			var got []ID
+			for _, message := range chat.VisibleMessages(tt.userID, messages) {
+				got = append(got, message.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VisibleMessages(%s) = %v, want %v", tt.userID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type JoinRequestStatus int
+
+const (
+	Pending JoinRequestStatus = iota + 1
+	Approved
+	Rejected
+)
+
+type Invite struct {
+	Code        string
+	ChatID      ID
+	CreatedBy   ID
+	UsageLimit  int // 0 means unlimited
+	UsageCount  int
+	Revoked     bool
+	CreatedTime *time.Time
+	ExpiresTime *time.Time // nil means the invite never expires
+}
+
+func (i Invite) IsUsable(now time.Time) bool {
+	if i.Revoked {
+		return false
+	}
+	if i.ExpiresTime != nil && !now.Before(*i.ExpiresTime) {
+		return false
+	}
+	return i.UsageLimit == 0 || i.UsageCount < i.UsageLimit
+}
+
+type JoinRequest struct {
+	ID          ID
+	ChatID      ID
+	UserID      ID
+	InviteCode  string
+	Status      JoinRequestStatus
+	CreatedTime *time.Time
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// LoginAttempts tracks recent failed logins for one key, a username or an
+// IP address.
+type LoginAttempts struct {
+	Key             string
+	Failures        int
+	LastFailureTime *time.Time
+	LockedUntil     *time.Time
+}
@@ -1,8 +1,14 @@
 package domain
 
+import "time"
+
 type Message struct {
-	ID       ID
-	SenderID ID
-	ChatID   ID
-	Content  string
+	ID          ID
+	SenderID    ID
+	ChatID      ID
+	Content     string
+	Attachments []Attachment
+	CreatedTime *time.Time
+	EditedTime  *time.Time
+	Views       int // channel posts only
 }
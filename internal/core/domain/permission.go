@@ -0,0 +1,111 @@
+package domain
+
+type Permission string
+
+const (
+	PermSendMessages         Permission = "send_messages"
+	PermSendMedia            Permission = "send_media"
+	PermAddMembers           Permission = "add_members"
+	PermRemoveMembers        Permission = "remove_members"
+	PermPinMessages          Permission = "pin_messages"
+	PermRenameChat           Permission = "rename_chat"
+	PermDeleteOthersMessages Permission = "delete_others_messages"
+	PermManageInvites        Permission = "manage_invites"
+	PermViewAuditLog         Permission = "view_audit_log"
+	PermRestrictMembers      Permission = "restrict_members" // ban and mute
+	PermManageSettings       Permission = "manage_settings"
+	PermChangeInfo           Permission = "change_info" // description, topic and avatar
+
+	// Owner-only permissions; roles and overrides cannot grant them.
+	PermManageAdmins      Permission = "manage_admins"
+	PermManagePermissions Permission = "manage_permissions"
+	PermDeleteChat        Permission = "delete_chat"
+	PermTransferOwnership Permission = "transfer_ownership"
+)
+
+var ownerOnlyPermissions = map[Permission]bool{
+	PermManageAdmins:      true,
+	PermManagePermissions: true,
+	PermDeleteChat:        true,
+	PermTransferOwnership: true,
+}
+
+func IsOwnerOnly(permission Permission) bool {
+	return ownerOnlyPermissions[permission]
+}
+
+// Only the owner and admins post in channels; roles and overrides cannot
+// let subscribers post.
+var channelPostingPermissions = map[Permission]bool{
+	PermSendMessages: true,
+	PermSendMedia:    true,
+}
+
+type Permissions map[Permission]bool
+
+// ChatPermissions configures what each role may do in a chat. Overrides
+// take precedence over the role: true grants, false revokes. Roles missing
+// from Roles fall back to DefaultRolePermissions.
+type ChatPermissions struct {
+	Roles     map[string]Permissions
+	Overrides map[ID]Permissions
+}
+
+func DefaultRolePermissions(chatType ChatType, role string) Permissions {
+	switch {
+	case chatType == Private:
+		return Permissions{PermSendMessages: true, PermSendMedia: true, PermPinMessages: true}
+	case role == Admin:
+		return Permissions{
+			PermSendMessages:         true,
+			PermSendMedia:            true,
+			PermAddMembers:           true,
+			PermRemoveMembers:        true,
+			PermPinMessages:          true,
+			PermDeleteOthersMessages: true,
+			PermManageInvites:        true,
+			PermViewAuditLog:         true,
+			PermRestrictMembers:      true,
+			PermManageSettings:       true,
+			PermChangeInfo:           true,
+		}
+	case role == Normal && chatType == Channel:
+		return Permissions{}
+	case role == Normal:
+		return Permissions{PermSendMessages: true, PermSendMedia: true}
+	}
+	return Permissions{}
+}
+
+// Can is the single policy check for chat actions. The owner may do
+// everything; other members get their role's permissions adjusted by any
+// per-member override, except for owner-only permissions and posting in
+// channels, which no override can grant. Private chats have no owner, so
+// either participant may delete them.
+func Can(chat Chat, userID ID, permission Permission) bool {
+	role := chat.RoleOf(userID)
+	if role == "" {
+		return false
+	}
+	if role == Owner {
+		return true
+	}
+	if chat.ChatType == Private && permission == PermDeleteChat {
+		return true
+	}
+	if IsOwnerOnly(permission) {
+		return false
+	}
+	if chat.ChatType == Channel && role != Admin && channelPostingPermissions[permission] {
+		return false
+	}
+
+	if allowed, ok := chat.Permissions.Overrides[userID][permission]; ok {
+		return allowed
+	}
+	rolePermissions, ok := chat.Permissions.Roles[role]
+	if !ok {
+		rolePermissions = DefaultRolePermissions(chat.ChatType, role)
+	}
+	return rolePermissions[permission]
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "testing"
+
+func TestCan(t *testing.T) {
+	group := Chat{
+		ChatType: Group,
+		Owner:    "owner",
+		Admins:   []ID{"admin"},
+		Members:  []ID{"owner", "admin", "member", "muted", "trusted"},
+		Permissions: ChatPermissions{
+			Overrides: map[ID]Permissions{
+				"muted":   {PermSendMessages: false},
+				"trusted": {PermRemoveMembers: true, PermManageAdmins: true},
+			},
+		},
+	}
+	channel := Chat{
+		ChatType: Channel,
+		Owner:    "owner",
+		Admins:   []ID{"admin"},
+		Members:  []ID{"owner", "admin", "subscriber", "promoted"},
+		Permissions: ChatPermissions{
+			Roles:     map[string]Permissions{Normal: {PermSendMedia: true}},
+			Overrides: map[ID]Permissions{"promoted": {PermSendMessages: true, PermPinMessages: true}},
+		},
+	}
+	private := Chat{ChatType: Private, Owner: "a", Members: []ID{"a", "b"}}
+	customGroup := group
+	customGroup.Permissions = ChatPermissions{Roles: map[string]Permissions{Normal: {PermAddMembers: true}}}
+
+	tests := []struct {
+		name       string
+		chat       Chat
+		userID     ID
+		permission Permission
+		want       bool
+	}{
+		{"owner can do anything", group, "owner", PermTransferOwnership, true},
+		{"non-member can do nothing", group, "stranger", PermSendMessages, false},
+		{"admin default", group, "admin", PermRestrictMembers, true},
+		{"admin cannot use owner-only permission", group, "admin", PermDeleteChat, false},
+		{"member default", group, "member", PermSendMessages, true},
+		{"member lacks admin permission", group, "member", PermRemoveMembers, false},
+		{"override revokes", group, "muted", PermSendMessages, false},
+		{"override grants", group, "trusted", PermRemoveMembers, true},
+		{"override cannot grant owner-only", group, "trusted", PermManageAdmins, false},
+		{"custom role replaces defaults", customGroup, "member", PermAddMembers, true},
+		{"custom role drops unlisted defaults", customGroup, "member", PermSendMessages, false},
+		{"channel admin posts", channel, "admin", PermSendMessages, true},
+		{"channel subscriber cannot post", channel, "subscriber", PermSendMessages, false},
+		{"channel role cannot grant posting", channel, "subscriber", PermSendMedia, false},
+		{"channel override cannot grant posting", channel, "promoted", PermSendMessages, false},
+		{"channel override grants other permissions", channel, "promoted", PermPinMessages, true},
+		{"private chat member posts", private, "b", PermSendMessages, true},
+		{"private chat has no admin permissions", private, "b", PermRenameChat, false},
+		{"either private chat member deletes", private, "b", PermDeleteChat, true},
+		{"private chat member cannot transfer", private, "a", PermTransferOwnership, false},
+		{"non-member cannot delete private chat", private, "c", PermDeleteChat, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Can(tt.chat, tt.userID, tt.permission); got != tt.want {
+				t.Errorf("Can(%s, %s) = %v, want %v", tt.userID, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Operation names an action that is rate limited.
+type Operation string
+
+const (
+	OpSendMessage  Operation = "send_message"
+	OpCreateChat   Operation = "create_chat"
+	OpCreateInvite Operation = "create_invite"
+	OpLogin        Operation = "login"
+)
+
+// UserTier selects which rate limits apply to a user. The zero value is
+// treated as TierStandard.
+type UserTier int
+
+const (
+	TierStandard UserTier = iota + 1
+	TierPremium
+)
+
+// RateLimit is a token bucket: up to Burst actions at once, refilled by one
+// every RefillEvery.
+type RateLimit struct {
+	Burst       int
+	RefillEvery time.Duration
+}
+
+type TokenBucket struct {
+	Tokens      float64
+	UpdatedTime time.Time // zero for a new, full bucket
+}
+
+// Take refills the bucket up to now and tries to take one token. It returns
+// the new bucket and, if no token was left, how long until one is.
+func (b TokenBucket) Take(limit RateLimit, now time.Time) (TokenBucket, time.Duration) {
+	capacity := float64(limit.Burst)
+	if b.UpdatedTime.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedTime); elapsed > 0 {
+		b.Tokens = min(capacity, b.Tokens+float64(elapsed)/float64(limit.RefillEvery))
+	}
+	b.UpdatedTime = now
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, 0
+	}
+	return b, time.Duration((1 - b.Tokens) * float64(limit.RefillEvery))
+}
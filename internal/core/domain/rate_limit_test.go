@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	limit := RateLimit{Burst: 3, RefillEvery: 10 * time.Second}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name      string
+		after     time.Duration // since start
+		wantRetry time.Duration
+	}{
+		{"new bucket is full", 0, 0},
+		{"second of the burst", 0, 0},
+		{"third of the burst", 0, 0},
+		{"burst used up", 0, 10 * time.Second},
+		{"partly refilled", 4 * time.Second, 6 * time.Second},
+		{"one token refilled", 10 * time.Second, 0},
+		{"empty again", 10 * time.Second, 10 * time.Second},
+		{"refill is capped at the burst", time.Hour, 0},
+		{"capped second", time.Hour, 0},
+		{"capped third", time.Hour, 0},
+		{"capped empty", time.Hour, 10 * time.Second},
+	}
+
+	var bucket TokenBucket
+	for _, step := range steps {
+		var retry time.Duration
+		bucket, retry = bucket.Take(limit, start.Add(step.after))
+		if retry != step.wantRetry {
+			t.Errorf("%s: retry after %v, want %v", step.name, retry, step.wantRetry)
+		}
+		if bucket.Tokens < 0 || bucket.Tokens > float64(limit.Burst) {
+			t.Errorf("%s: %v tokens outside [0, %d]", step.name, bucket.Tokens, limit.Burst)
+		}
+	}
+}
+
+func TestTokenBucketClockGoingBack(t *testing.T) {
+	limit := RateLimit{Burst: 1, RefillEvery: time.Minute}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	bucket, _ := TokenBucket{}.Take(limit, now)
+	if _, retry := bucket.Take(limit, now.Add(-time.Hour)); retry != time.Minute {
+		t.Errorf("retry after %v when the clock went back, want %v", retry, time.Minute)
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+type RestrictionType int
+
+const (
+	Banned RestrictionType = iota + 1
+	Muted
+)
+
+func (t RestrictionType) String() string {
+	switch t {
+	case Banned:
+		return "banned"
+	case Muted:
+		return "muted"
+	}
+	return "unknown"
+}
+
+// Restriction is a moderation action against a chat member. A banned user
+// is removed and cannot rejoin; a muted user can read but not post.
+type Restriction struct {
+	ChatID      ID
+	UserID      ID
+	IssuedBy    ID
+	Type        RestrictionType
+	Reason      string
+	CreatedTime *time.Time
+	ExpiresTime *time.Time // nil means the restriction is permanent
+}
+
+func (r Restriction) IsActive(now time.Time) bool {
+	return r.ExpiresTime == nil || now.Before(*r.ExpiresTime)
+}
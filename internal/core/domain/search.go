@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// SearchQuery describes a full-text search over messages. Text may contain
+// quoted phrases, e.g. `deploy "release notes"`.
+type SearchQuery struct {
+	Text     string
+	ChatID   ID // optional, limits the search to a single chat
+	SenderID ID // optional
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+
+	ExcludeSenders []ID // filled in from the caller's block list
+}
+
+type SearchResult struct {
+	Message Message
+	Score   float64
+	Snippet string // content excerpt with matched terms wrapped in **
+}
@@ -1,8 +1,11 @@
 package domain
 
+// Session lists the user's chats by ID. Names are only for display: two
+// chats can be listed under the same name, e.g. private chats with two
+// contacts who share a display name.
 type Session struct {
 	SessionID     ID
 	UserID        ID
-	ChatNameAndID map[string]string // map[chatName]chatID
+	ChatIDAndName map[string]string // map[chatID]chatName
 	ChatNameList  []string
 }
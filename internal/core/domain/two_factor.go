@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// TwoFactor is a user's TOTP authenticator enrolment. It only protects
+// logins once Enabled, which happens after the first valid code.
+type TwoFactor struct {
+	UserID        ID
+	Secret        string // base32, as shown to authenticator apps
+	Enabled       bool
+	LastCounter   int64    // time step of the last accepted code, to stop replays
+	RecoveryCodes []string // SHA-256 hashes of the unused recovery codes
+	EnabledTime   *time.Time
+}
+
+// LoginChallenge is a login that passed the password check and still needs
+// an authenticator or recovery code. When two-factor authentication is
+// required but the user has not enrolled yet, ProvisioningURI is set and
+// the code confirms the enrolment instead.
+type LoginChallenge struct {
+	ID              ID
+	UserID          ID
+	ProvisioningURI string
+	Attempts        int
+	ExpiresTime     *time.Time
+}
+
+// LoginResult is the outcome of a login step: either a session, or a
+// challenge to answer with CompleteLogin.
+type LoginResult struct {
+	Session   *Session
+	Challenge *LoginChallenge
+	// RecoveryCodes are shown once, when the login completed an enrolment.
+	RecoveryCodes []string
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Gender int
 
@@ -15,20 +18,131 @@ type ChatType int
 const (
 	Private ChatType = iota + 1
 	Group
+	Channel
 )
 
 type ID string
 
 type User struct {
+	ID        ID
+	Username  string
+	FirstName string
+	LastName  string
+	Password  string
+	Gender    Gender
+	Email     string
+	// EmailVerifiedTime is set once the user has proven they own Email,
+	// and cleared whenever Email changes.
+	EmailVerifiedTime *time.Time
+	Contacts          []ID
+	Blocked           []ID
+	Avatar            *Attachment
+	Bio               string
+	Privacy           PrivacySettings
+	Tier              UserTier // selects the rate limits that apply
+	DateOfBirth       *time.Time
+	CreatedTime       *time.Time
+	// PasswordChangedTime is set whenever the password changes, so that
+	// password reset links sent before then stop working.
+	PasswordChangedTime *time.Time
+	// DeletedTime is set when the user deletes their account. Logging in
+	// again within the grace period cancels the deletion.
+	DeletedTime *time.Time
+	// AnonymizedTime is set once the grace period has passed and the
+	// account's personal data has been removed.
+	AnonymizedTime *time.Time
+}
+
+// DeletedUserName is shown in place of the name of an anonymized user.
+const DeletedUserName = "Deleted user"
+
+// DeletedUserMessages decides what happens to a deleted user's messages
+// once their account is anonymized.
+type DeletedUserMessages int
+
+const (
+	KeepMessages DeletedUserMessages = iota + 1 // kept, attributed to DeletedUserName
+	PurgeMessages
+)
+
+func (u User) HasBlocked(userID ID) bool {
+	for _, blocked := range u.Blocked {
+		if blocked == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (u User) DisplayName() string {
+	if u.AnonymizedTime != nil {
+		return DeletedUserName
+	}
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		return u.Username
+	}
+	return name
+}
+
+// PublicProfile is what other users may see about a user.
+type PublicProfile struct {
 	ID          ID
 	Username    string
 	FirstName   string
 	LastName    string
-	Password    string
-	Gender      Gender
-	Email       string
-	Contacts    []ID
-	DateOfBirth *time.Time
-	CreatedTime *time.Time
-	DeletedTime *time.Time
+	DisplayName string
+	Avatar      *Attachment
+	Bio         string
+}
+
+func (u User) PublicProfile() PublicProfile {
+	return PublicProfile{
+		ID:          u.ID,
+		Username:    u.Username,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		DisplayName: u.DisplayName(),
+		Avatar:      u.Avatar,
+		Bio:         u.Bio,
+	}
+}
+
+// CanBeFoundBy reports whether the searcher may find the user in the
+// directory. exactEmail is true when the search was an exact email match.
+func (u User) CanBeFoundBy(searcher User, exactEmail bool) bool {
+	if u.DeletedTime != nil || u.ID == searcher.ID {
+		return false
+	}
+	if u.HasBlocked(searcher.ID) || searcher.HasBlocked(u.ID) {
+		return false
+	}
+
+	switch u.Privacy.Discoverability {
+	case NotDiscoverable:
+		return false
+	case DiscoverableByEmailOnly:
+		return exactEmail
+	case DiscoverableByContacts:
+		for _, contact := range u.Contacts {
+			if contact == searcher.ID {
+				return true
+			}
+		}
+		return exactEmail
+	}
+	return true
+}
+
+// ProfileUpdate changes a user's own profile. Nil fields are left as they are.
+type ProfileUpdate struct {
+	Username     *string
+	FirstName    *string
+	LastName     *string
+	Email        *string
+	Avatar       *Attachment
+	RemoveAvatar bool
+	Bio          *string
+	DateOfBirth  *time.Time
+	Privacy      *PrivacySettings
 }
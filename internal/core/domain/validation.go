@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinUsernameLength  = 3
+	MaxUsernameLength  = 32
+	MaxNameLength      = 64
+	MaxEmailLength     = 254
+	MaxChatNameLength  = 128
+	MaxMessageLength   = 4096
+	MinUserAge         = 13
+	MaxUserAge         = 150
+	MinPasswordLength  = 8
+	MaxPasswordLength  = 128
+	usernameCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_."
+)
+
+// FieldError is one invalid field. Field names the input, e.g. "email" or
+// "chat.name", so that clients can show the message next to it.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every invalid field of an input. It has the
+// invalid_argument code.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "invalid input: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) ErrorCode() ErrorCode {
+	return CodeInvalidArgument
+}
+
+// Validator collects field errors so that all of them are reported at once.
+// The zero value is ready to use.
+type Validator struct {
+	fields []FieldError
+}
+
+// Check records message for field unless ok holds.
+func (v *Validator) Check(ok bool, field, format string, args ...any) {
+	if !ok {
+		v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// Err returns a *ValidationError with the collected field errors, or nil.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// Text checks that value is valid UTF-8 of at most max characters.
+func (v *Validator) Text(field, value string, max int) {
+	if !utf8.ValidString(value) {
+		v.Check(false, field, "must be valid UTF-8")
+		return
+	}
+	v.Check(utf8.RuneCountInString(value) <= max, field, "cannot be longer than %d characters", max)
+}
+
+// Name is a required piece of text such as a first or last name.
+func (v *Validator) Name(field, value string, max int) {
+	if strings.TrimSpace(value) == "" {
+		v.Required(field, value)
+		return
+	}
+	v.Text(field, value, max)
+}
+
+func (v *Validator) Email(field, value string) {
+	if value == "" {
+		v.Required(field, value)
+		return
+	}
+	address, err := mail.ParseAddress(value)
+	v.Check(err == nil && address.Address == value && len(value) <= MaxEmailLength, field, "must be a valid email address")
+}
+
+// Username allows letters, digits, underscores and dots, starting with a letter.
+func (v *Validator) Username(field, value string) {
+	if value == "" {
+		v.Required(field, value)
+		return
+	}
+	v.Check(len(value) >= MinUsernameLength && len(value) <= MaxUsernameLength, field,
+		"must be between %d and %d characters", MinUsernameLength, MaxUsernameLength)
+	v.Check(strings.Trim(value, usernameCharacters) == "", field, "may only contain letters, digits, underscores and dots")
+	v.Check(strings.ContainsAny(value[:1], usernameCharacters[:52]), field, "must start with a letter")
+}
+
+func (v *Validator) Password(field, value string) {
+	v.Check(len(value) >= MinPasswordLength, field, "must be at least %d characters", MinPasswordLength)
+	v.Check(len(value) <= MaxPasswordLength, field, "cannot be longer than %d characters", MaxPasswordLength)
+}
+
+func (v *Validator) ChatName(field, value string) {
+	v.Name(field, value, MaxChatNameLength)
+}
+
+// MessageContent checks a message body; captions may be empty.
+func (v *Validator) MessageContent(field, value string, required bool) {
+	if required && strings.TrimSpace(value) == "" {
+		v.Required(field, value)
+		return
+	}
+	v.Text(field, value, MaxMessageLength)
+}
+
+// DateOfBirth rejects missing dates, dates in the future and ages outside
+// MinUserAge to MaxUserAge.
+func (v *Validator) DateOfBirth(field string, value *time.Time, now time.Time) {
+	if value == nil {
+		v.Check(false, field, "is required")
+		return
+	}
+	switch {
+	case value.After(now):
+		v.Check(false, field, "cannot be in the future")
+	case value.After(now.AddDate(-MinUserAge, 0, 0)):
+		v.Check(false, field, "users must be at least %d years old", MinUserAge)
+	case value.Before(now.AddDate(-MaxUserAge, 0, 0)):
+		v.Check(false, field, "is not a plausible date of birth")
+	}
+}
+
+// FieldErrorsOf returns the field errors of the first *ValidationError in
+// err's chain, so that transports can report them per field.
+func FieldErrorsOf(err error) []FieldError {
+	var v *ValidationError
+	if errors.As(err, &v) {
+		return v.Fields
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidator(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	date := func(years int) *time.Time {
+		d := now.AddDate(-years, 0, 0)
+		return &d
+	}
+	tomorrow := now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name     string
+		validate func(v *Validator)
+		want     []FieldError
+	}{
+		{"valid email", func(v *Validator) { v.Email("email", "bob@example.com") }, nil},
+		{"missing email", func(v *Validator) { v.Email("email", "") }, []FieldError{{"email", "is required"}}},
+		{"malformed email", func(v *Validator) { v.Email("email", "bob.example.com") }, []FieldError{{"email", "must be a valid email address"}}},
+		{"email with display name", func(v *Validator) { v.Email("email", "Bob <bob@example.com>") }, []FieldError{{"email", "must be a valid email address"}}},
+		{"too long email", func(v *Validator) { v.Email("email", strings.Repeat("a", 64)+"@"+strings.Repeat("b", 190)+".com") }, []FieldError{{"email", "must be a valid email address"}}},
+
+		{"valid username", func(v *Validator) { v.Username("username", "bob_smith.2") }, nil},
+		{"missing username", func(v *Validator) { v.Username("username", "") }, []FieldError{{"username", "is required"}}},
+		{"short username", func(v *Validator) { v.Username("username", "bo") }, []FieldError{{"username", "must be between 3 and 32 characters"}}},
+		{"long username", func(v *Validator) { v.Username("username", strings.Repeat("b", 33)) }, []FieldError{{"username", "must be between 3 and 32 characters"}}},
+		{"username with bad characters", func(v *Validator) { v.Username("username", "bob smith") }, []FieldError{{"username", "may only contain letters, digits, underscores and dots"}}},
+		{"username starting with a digit", func(v *Validator) { v.Username("username", "1bob") }, []FieldError{{"username", "must start with a letter"}}},
+		{"username with several problems", func(v *Validator) { v.Username("username", "_") }, []FieldError{
+			{"username", "must be between 3 and 32 characters"},
+			{"username", "must start with a letter"},
+		}},
+
+		{"valid chat name", func(v *Validator) { v.ChatName("name", "Book club") }, nil},
+		{"blank chat name", func(v *Validator) { v.ChatName("name", "   ") }, []FieldError{{"name", "is required"}}},
+		{"chat name counts characters, not bytes", func(v *Validator) { v.ChatName("name", strings.Repeat("é", MaxChatNameLength)) }, nil},
+		{"long chat name", func(v *Validator) { v.ChatName("name", strings.Repeat("é", MaxChatNameLength+1)) }, []FieldError{{"name", "cannot be longer than 128 characters"}}},
+
+		{"valid message", func(v *Validator) { v.MessageContent("message", "hello", true) }, nil},
+		{"missing message", func(v *Validator) { v.MessageContent("message", " \n", true) }, []FieldError{{"message", "is required"}}},
+		{"empty caption", func(v *Validator) { v.MessageContent("caption", "", false) }, nil},
+		{"message at the limit", func(v *Validator) { v.MessageContent("message", strings.Repeat("é", MaxMessageLength), true) }, nil},
+		{"long message", func(v *Validator) { v.MessageContent("message", strings.Repeat("é", MaxMessageLength+1), true) }, []FieldError{{"message", "cannot be longer than 4096 characters"}}},
+		{"message with invalid UTF-8", func(v *Validator) { v.MessageContent("message", "hi \xff", true) }, []FieldError{{"message", "must be valid UTF-8"}}},
+
+		{"valid date of birth", func(v *Validator) { v.DateOfBirth("date_of_birth", date(30), now) }, nil},
+		{"exactly the minimum age", func(v *Validator) { v.DateOfBirth("date_of_birth", date(MinUserAge), now) }, nil},
+		{"missing date of birth", func(v *Validator) { v.DateOfBirth("date_of_birth", nil, now) }, []FieldError{{"date_of_birth", "is required"}}},
+		{"date of birth in the future", func(v *Validator) { v.DateOfBirth("date_of_birth", &tomorrow, now) }, []FieldError{{"date_of_birth", "cannot be in the future"}}},
+		{"too young", func(v *Validator) { v.DateOfBirth("date_of_birth", date(MinUserAge-1), now) }, []FieldError{{"date_of_birth", "users must be at least 13 years old"}}},
+		{"too old", func(v *Validator) { v.DateOfBirth("date_of_birth", date(MaxUserAge+1), now) }, []FieldError{{"date_of_birth", "is not a plausible date of birth"}}},
+
+		{"short password", func(v *Validator) { v.Password("password", "secret") }, []FieldError{{"password", "must be at least 8 characters"}}},
+		{"errors of several fields are all kept", func(v *Validator) {
+			v.Email("email", "")
+			v.Username("username", "bob")
+			v.ChatName("name", "")
+		}, []FieldError{{"email", "is required"}, {"name", "is required"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.validate(&v)
+			err := v.Err()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+				return
+			}
+			if got := FieldErrorsOf(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("field errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorCode(t *testing.T) {
+	var v Validator
+	v.Required("email", "")
+	v.Required("username", "")
+	err := Wrap(v.Err(), "failed to create user")
+
+	if got := CodeOf(err); got != CodeInvalidArgument {
+		t.Errorf("CodeOf = %v, want %v", got, CodeInvalidArgument)
+	}
+	want := []FieldError{{"email", "is required"}, {"username", "is required"}}
+	if got := FieldErrorsOf(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldErrorsOf = %v, want %v", got, want)
+	}
+	if got := v.Err().Error(); got != "invalid input: email: is required; username: is required" {
+		t.Errorf("Error() = %q", got)
+	}
+	if FieldErrorsOf(errors.New("plain")) != nil {
+		t.Error("FieldErrorsOf found fields in an error without any")
+	}
+}
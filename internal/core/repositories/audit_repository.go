@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+)
+
+type AuditRepository interface {
+	AddRecord(ctx context.Context, event domain.Event) error
+	ListRecords(ctx context.Context, chatID domain.ID) ([]domain.Event, error)
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
@@ -2,23 +2,41 @@ package repositories
 
 import (
 	"chat-app/internal/core/domain"
-	"errors"
+	"context"
+	"time"
 )
 
 var (
-	ErrChatNotFound         = errors.New("chat not found")
-	ErrMissingChatParameter = errors.New("missing chat parameters")
-	ErrDuplicateChat        = errors.New("duplicate chat")
+	ErrChatNotFound         = domain.NotFound("chat not found")
+	ErrMissingChatParameter = domain.InvalidArgument("missing chat parameters")
+	ErrDuplicateChat        = domain.Conflict("duplicate chat")
 )
 
 type ChatRepository interface {
-	CreateChat(chat domain.Chat) (chatID domain.ID, err error)
-	FindChat(chatID domain.ID) (chat domain.Chat, err error)
-	UpdateChatName(chat domain.Chat) error
-	DeleteChat(chatID domain.ID) error
-	GetMessages(chatID domain.ID) ([]domain.Message, error)
-	AddUser(chatID domain.ID, userIDs []domain.ID) error
-	RemoveUser(chatID domain.ID, userID []domain.ID) error
-	GetMembers(chatID domain.ID) ([]domain.ID, error)
-	SetAdmin(userID, chatID domain.ID) error
+	// CreateChat fails with ErrDuplicateChat if a private chat with the same
+	// Name, which identifies the pair, already exists and is not deleted.
+	// The check must be atomic with the insert, e.g. a partial unique index.
+	CreateChat(ctx context.Context, chat domain.Chat) (chatID domain.ID, err error)
+	FindChat(ctx context.Context, chatID domain.ID) (chat domain.Chat, err error)
+	// FindPrivateChat returns the pair's private chat that is not deleted.
+	FindPrivateChat(ctx context.Context, userID, otherUserID domain.ID) (chat domain.Chat, err error)
+	// UpdateChat saves the chat's name, profile and settings.
+	UpdateChat(ctx context.Context, chat domain.Chat) error
+	// SetDeletedTime soft-deletes the chat, or restores it when deletedTime is nil.
+	SetDeletedTime(ctx context.Context, chatID domain.ID, deletedTime *time.Time) error
+	ListDeletedChats(ctx context.Context, deletedBefore time.Time) ([]domain.Chat, error)
+	// DeleteChat removes the chat permanently.
+	DeleteChat(ctx context.Context, chatID domain.ID) error
+	// GetMessages returns the chat's messages, oldest first.
+	GetMessages(ctx context.Context, chatID domain.ID) ([]domain.Message, error)
+	// AddUser adds the users as members and records their join time.
+	AddUser(ctx context.Context, chatID domain.ID, userIDs []domain.ID) error
+	RemoveUser(ctx context.Context, chatID domain.ID, userID []domain.ID) error
+	GetMembers(ctx context.Context, chatID domain.ID) ([]domain.ID, error)
+	SetAdmin(ctx context.Context, userID, chatID domain.ID) error
+	RemoveAdmin(ctx context.Context, userID, chatID domain.ID) error
+	SetOwner(ctx context.Context, userID, chatID domain.ID) error
+	UpdatePermissions(ctx context.Context, chatID domain.ID, permissions domain.ChatPermissions) error
+	PinMessage(ctx context.Context, chatID, messageID domain.ID) error
+	UnpinMessage(ctx context.Context, chatID, messageID domain.ID) error
 }
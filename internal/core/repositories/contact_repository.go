@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+)
+
+var (
+	ErrContactRequestNotFound = domain.NotFound("contact request not found")
+)
+
+type ContactRepository interface {
+	AddContact(ctx context.Context, userID, contactID domain.ID) error
+	RemoveContact(ctx context.Context, userID, contactID domain.ID) error
+	CreateContactRequest(ctx context.Context, request domain.ContactRequest) error
+	FindContactRequest(ctx context.Context, fromUserID, toUserID domain.ID) (domain.ContactRequest, error)
+	ListContactRequests(ctx context.Context, toUserID domain.ID) ([]domain.ContactRequest, error)
+	DeleteContactRequest(ctx context.Context, requestID domain.ID) error
+	// DeleteUserContacts removes the user's contacts, the user from everyone
+	// else's contacts, and every contact request from or to the user.
+	DeleteUserContacts(ctx context.Context, userID domain.ID) error
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var (
+	ErrExportNotFound     = domain.NotFound("data export not found")
+	ErrExportNotAvailable = domain.Conflict("data export is not ready, already downloaded or expired")
+)
+
+type ExportRepository interface {
+	CreateExport(ctx context.Context, export domain.DataExport) error
+	FindExport(ctx context.Context, exportID domain.ID) (domain.DataExport, error)
+	UpdateExport(ctx context.Context, export domain.DataExport) error
+	// MarkExportDownloaded atomically moves a ready export that has not
+	// expired at now to downloaded, and returns ErrExportNotAvailable if it
+	// is in any other state, so that only one download can ever succeed.
+	MarkExportDownloaded(ctx context.Context, exportID domain.ID, now time.Time) error
+	ListPendingExports(ctx context.Context) ([]domain.DataExport, error)
+	// ListExpiredExports returns exports that expired before the given time,
+	// or were already downloaded, and still have an archive stored.
+	ListExpiredExports(ctx context.Context, before time.Time) ([]domain.DataExport, error)
+	SaveArchive(ctx context.Context, exportID domain.ID, archive []byte) error
+	LoadArchive(ctx context.Context, exportID domain.ID) ([]byte, error)
+	DeleteArchive(ctx context.Context, exportID domain.ID) error
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var (
+	ErrInviteNotFound      = domain.NotFound("invite not found")
+	ErrInviteNotUsable     = domain.Forbidden("invite is expired, revoked or used up")
+	ErrJoinRequestNotFound = domain.NotFound("join request not found")
+	ErrJoinRequestPending  = domain.Conflict("a join request for this chat is already pending")
+)
+
+type InviteRepository interface {
+	CreateInvite(ctx context.Context, invite domain.Invite) error
+	FindInvite(ctx context.Context, code string) (domain.Invite, error)
+	ListInvites(ctx context.Context, chatID domain.ID) ([]domain.Invite, error)
+	UpdateInvite(ctx context.Context, invite domain.Invite) error
+	// IncrementInviteUsage atomically counts one use of the invite if it is
+	// still usable at now, and returns the updated invite. It returns
+	// ErrInviteNotUsable otherwise, so that concurrent joins can never go
+	// over the usage limit.
+	IncrementInviteUsage(ctx context.Context, code string, now time.Time) (domain.Invite, error)
+	// CreateJoinRequest fails with ErrJoinRequestPending if the user already
+	// has a pending request for the chat. The check must be atomic with the
+	// insert, e.g. a partial unique index, so that concurrent requests from
+	// the same user cannot both be stored.
+	CreateJoinRequest(ctx context.Context, request domain.JoinRequest) error
+	FindJoinRequest(ctx context.Context, requestID domain.ID) (domain.JoinRequest, error)
+	ListJoinRequests(ctx context.Context, chatID domain.ID) ([]domain.JoinRequest, error)
+	UpdateJoinRequest(ctx context.Context, request domain.JoinRequest) error
+}
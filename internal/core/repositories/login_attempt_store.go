@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var (
+	ErrAccountLocked   = domain.RateLimited("account is temporarily locked after too many failed logins")
+	ErrTooManyAttempts = domain.RateLimited("too many failed logins, wait before trying again")
+)
+
+// LoginAttemptStore keeps failed login counters. AddLoginFailure must be
+// atomic so that concurrent attempts are all counted.
+type LoginAttemptStore interface {
+	// GetLoginAttempts returns an empty record for keys without failures.
+	GetLoginAttempts(ctx context.Context, key string) (domain.LoginAttempts, error)
+	// AddLoginFailure counts a failure at now and returns the updated record.
+	// The count restarts from zero if the previous failure is older than
+	// window.
+	AddLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
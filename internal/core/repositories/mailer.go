@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+)
+
+// Mailer sends outbound email. See internal/infrastructure/mail for an SMTP
+// implementation and sinks for local development and tests.
+type Mailer interface {
+	Send(ctx context.Context, mail domain.Mail) error
+}
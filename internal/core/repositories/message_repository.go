@@ -1,8 +1,30 @@
 package repositories
 
-import "chat-app/internal/core/domain"
+import (
+	"chat-app/internal/core/domain"
+	"context"
+)
+
+var (
+	ErrMessageNotFound = domain.NotFound("message not found")
+	ErrSlowMode        = domain.RateLimited("slow mode is enabled in this chat")
+)
 
 type MessageRepository interface {
-	SendMessage(chatID, userID domain.ID, message string) error
-	DeleteMessage(chatID, userID, messageID domain.ID) error
+	SendMessage(ctx context.Context, chatID, userID domain.ID, message string) (domain.Message, error)
+	SendMedia(ctx context.Context, chatID, userID domain.ID, caption string, attachments []domain.Attachment) (domain.Message, error)
+	FindMessage(ctx context.Context, messageID domain.ID) (domain.Message, error)
+	EditMessage(ctx context.Context, chatID, userID, messageID domain.ID, message string) (domain.Message, error)
+	DeleteMessage(ctx context.Context, chatID, userID, messageID domain.ID) error
+	DeleteChatMessages(ctx context.Context, chatID domain.ID) error
+	// DeleteUserMessages deletes every message the user has sent, in all chats.
+	DeleteUserMessages(ctx context.Context, userID domain.ID) error
+	// ListUserMessages returns every message the user has sent, oldest first.
+	ListUserMessages(ctx context.Context, userID domain.ID) ([]domain.Message, error)
+	// ListMessages pages through all messages in ID order, returning up to
+	// limit messages with an ID greater than afterID.
+	ListMessages(ctx context.Context, afterID domain.ID, limit int) ([]domain.Message, error)
+	// AddView records that the user has seen the message; repeated views by
+	// the same user are counted once.
+	AddView(ctx context.Context, messageID, userID domain.ID) error
 }
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var ErrRateLimited = domain.RateLimited("rate limit exceeded")
+
+// RateLimitStore holds token buckets. Take must refill and take from the
+// bucket atomically, e.g. with domain.TokenBucket.Take under a lock or in a
+// script on a shared store, so that limits hold across processes.
+type RateLimitStore interface {
+	// Take returns zero if a token was taken, or how long until one is
+	// available.
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (retryAfter time.Duration, err error)
+}
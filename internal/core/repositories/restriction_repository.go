@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var (
+	ErrRestrictionNotFound = domain.NotFound("restriction not found")
+	ErrUserBanned          = domain.Forbidden("user is banned from this chat")
+	ErrUserMuted           = domain.Forbidden("user is muted in this chat")
+)
+
+type RestrictionRepository interface {
+	// AddRestriction replaces any restriction of the same type for the user.
+	AddRestriction(ctx context.Context, restriction domain.Restriction) error
+	FindRestriction(ctx context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) (domain.Restriction, error)
+	ListRestrictions(ctx context.Context, chatID domain.ID) ([]domain.Restriction, error)
+	RemoveRestriction(ctx context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) error
+	DeleteExpiredRestrictions(ctx context.Context, now time.Time) (deleted int, err error)
+	// ReservePost enforces slow mode for one member. If the member's last
+	// post is less than interval before now it returns the remaining wait;
+	// otherwise it records now as their last post and returns zero. Both
+	// happen atomically.
+	ReservePost(ctx context.Context, chatID, userID domain.ID, now time.Time, interval time.Duration) (remaining time.Duration, err error)
+}
@@ -2,16 +2,27 @@ package repositories
 
 import (
 	"chat-app/internal/core/domain"
+	"context"
 	"time"
 )
 
+var ErrSessionNotFound = domain.Unauthenticated("session not found or expired")
+
 type SessionRepository interface {
-	CreateSession(session domain.Session, ttl time.Duration) error
-	GetSession(sessionID domain.ID) (domain.Session, error)
-	GetSessionByUserID(userID domain.ID) (domain.Session, error)
-	AddChatToSession(sessionID domain.ID, chatID domain.ID, role string) error
-	RemoveChatFromSession(sessionID domain.ID, chatID domain.ID) error
-	UpdateChatRole(sessionID domain.ID, chatID domain.ID, role string) error
-	IsUserInChat(sessionID domain.ID, chatID domain.ID) (role string, err error)
-	DeleteSession(sessionID domain.ID) error
+	CreateSession(ctx context.Context, session domain.Session, ttl time.Duration) error
+	// GetSession fails with ErrSessionNotFound for unknown or expired sessions.
+	GetSession(ctx context.Context, sessionID domain.ID) (domain.Session, error)
+	GetSessionByUserID(ctx context.Context, userID domain.ID) (domain.Session, error)
+	// ListUserSessions returns the user's sessions that have not expired,
+	// oldest first.
+	ListUserSessions(ctx context.Context, userID domain.ID) ([]domain.Session, error)
+	// AddChatToSession lists the chat under chatName, replacing any entry
+	// for the same chatID.
+	AddChatToSession(ctx context.Context, sessionID domain.ID, chatID domain.ID, chatName string, role string) error
+	RemoveChatFromSession(ctx context.Context, sessionID domain.ID, chatID domain.ID) error
+	UpdateChatRole(ctx context.Context, sessionID domain.ID, chatID domain.ID, role string) error
+	IsUserInChat(ctx context.Context, sessionID domain.ID, chatID domain.ID) (role string, err error)
+	DeleteSession(ctx context.Context, sessionID domain.ID) error
+	// DeleteUserSessions revokes every session of the user.
+	DeleteUserSessions(ctx context.Context, userID domain.ID) error
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"time"
+)
+
+var (
+	ErrInvalidToken = domain.InvalidArgument("token is invalid or expired")
+	ErrTokenUsed    = domain.InvalidArgument("token was already used")
+)
+
+// TokenRepository remembers which single-use tokens have been consumed.
+type TokenRepository interface {
+	// MarkTokenUsed records the token as used, or fails with ErrTokenUsed if
+	// it already was. Records may be dropped once expiresTime has passed.
+	MarkTokenUsed(ctx context.Context, tokenID string, expiresTime time.Time) error
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+)
+
+var (
+	ErrTwoFactorNotFound = domain.NotFound("two-factor authentication is not set up")
+	ErrChallengeNotFound = domain.Unauthenticated("login challenge not found or expired")
+	ErrInvalidCode       = domain.Unauthenticated("invalid authentication code")
+)
+
+type TwoFactorRepository interface {
+	FindTwoFactor(ctx context.Context, userID domain.ID) (domain.TwoFactor, error)
+	SaveTwoFactor(ctx context.Context, twoFactor domain.TwoFactor) error
+	DeleteTwoFactor(ctx context.Context, userID domain.ID) error
+	CreateChallenge(ctx context.Context, challenge domain.LoginChallenge) error
+	FindChallenge(ctx context.Context, challengeID domain.ID) (domain.LoginChallenge, error)
+	UpdateChallenge(ctx context.Context, challenge domain.LoginChallenge) error
+	DeleteChallenge(ctx context.Context, challengeID domain.ID) error
+}
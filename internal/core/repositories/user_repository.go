@@ -2,17 +2,42 @@ package repositories
 
 import (
 	"chat-app/internal/core/domain"
-	"errors"
+	"context"
+	"time"
 )
 
 var (
-	ErrUserNotFound   = errors.New("user not found")
-	ErrWrongLoginInfo = errors.New("wrong login info")
+	ErrUserNotFound     = domain.NotFound("user not found")
+	ErrWrongLoginInfo   = domain.Unauthenticated("wrong login info")
+	ErrUserBlocked      = domain.Forbidden("user is blocked")
+	ErrDuplicateUser    = domain.Conflict("username or email is already taken")
+	ErrEmailNotVerified = domain.Forbidden("email address is not verified")
 )
 
 type UserRepository interface {
-	Register(user domain.User) (userID domain.ID, err error)
-	Login(username, password string) (userID domain.ID, err error)
-	GetChatIDList(userID domain.ID) (chatIDList []string, err error)
-	GetUserInfo(userID domain.ID) (user domain.User, err error)
+	Register(ctx context.Context, user domain.User) (userID domain.ID, err error)
+	Login(ctx context.Context, username, password string) (userID domain.ID, err error)
+	GetChatIDList(ctx context.Context, userID domain.ID) (chatIDList []string, err error)
+	GetUserInfo(ctx context.Context, userID domain.ID) (user domain.User, err error)
+	FindUserByUsername(ctx context.Context, username string) (user domain.User, err error)
+	FindUserByEmail(ctx context.Context, email string) (user domain.User, err error)
+	// SearchUsers returns up to limit users, ordered by username, whose
+	// username starts with text or whose first or last name starts with
+	// any word of text, skipping the first offset matches.
+	SearchUsers(ctx context.Context, text string, limit, offset int) ([]domain.User, error)
+	// UpdateUser saves every field of the user except Password and
+	// PasswordChangedTime, which it never writes: only Register and
+	// UpdatePassword set the password, so a user read back through
+	// GetUserInfo, whose Password is blank, can be saved as is.
+	UpdateUser(ctx context.Context, user domain.User) error
+	// UpdatePassword sets the password and PasswordChangedTime to now.
+	UpdatePassword(ctx context.Context, userID domain.ID, password string) error
+	BlockUser(ctx context.Context, userID, blockedID domain.ID) error
+	UnblockUser(ctx context.Context, userID, blockedID domain.ID) error
+	// SetDeletedTime marks the account as deleted, or cancels the deletion
+	// when deletedTime is nil.
+	SetDeletedTime(ctx context.Context, userID domain.ID, deletedTime *time.Time) error
+	// ListDeletedUsers returns users deleted before the given time that have
+	// not been anonymized yet.
+	ListDeletedUsers(ctx context.Context, deletedBefore time.Time) ([]domain.User, error)
 }
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditService struct {
+	Audit     repositories.AuditRepository
+	Publisher repositories.EventPublisher
+}
+
+func NewAuditService(audit repositories.AuditRepository, publisher repositories.EventPublisher) *AuditService {
+	return &AuditService{
+		Audit:     audit,
+		Publisher: publisher,
+	}
+}
+
+// Record stores the event in the audit log and then publishes it.
+func (as *AuditService) Record(ctx context.Context, event domain.Event) error {
+	if event.Type == "" {
+		return domain.InvalidArgument("event type cannot be empty")
+	}
+	if event.ChatID == "" {
+		return domain.InvalidArgument("event chatID cannot be empty")
+	}
+
+	now := time.Now()
+	event.ID = domain.ID(uuid.New().String())
+	event.CreatedTime = &now
+
+	if err := as.Audit.AddRecord(ctx, event); err != nil {
+		return domain.Wrap(err, "failed to add audit record")
+	}
+	if err := as.Publisher.Publish(ctx, event); err != nil {
+		return domain.Wrap(err, "failed to publish event")
+	}
+	return nil
+}
+
+func (as *AuditService) ListRecords(ctx context.Context, chatID domain.ID) ([]domain.Event, error) {
+	if chatID == "" {
+		return nil, domain.InvalidArgument("chatID cannot be empty")
+	}
+	records, err := as.Audit.ListRecords(ctx, chatID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list audit records")
+	}
+	return records, nil
+}
@@ -3,58 +3,72 @@ package services
 import (
 	"chat-app/internal/core/domain"
 	"chat-app/internal/core/repositories"
+	"context"
 	"errors"
-	"fmt"
+	"strings"
+	"time"
 )
 
+// DefaultDeletionGracePeriod is how long a deleted chat can be restored
+// before it is purged.
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
 type ChatService struct {
-	Chat repositories.ChatRepository
+	Chat                repositories.ChatRepository
+	DeletionGracePeriod time.Duration
 }
 
-//type ChatRepository interface {
-//	CreateChat(chat domain.Chat) (chatID domain.ID, err error)
-//	FindChat(chatID domain.ID) (chat domain.Chat, err error)
-//	UpdateChatName(chat domain.Chat) error
-//	DeleteChat(chatID domain.ID) error
-//	GetMessages(chatID domain.ID) ([]domain.Message, error)
-//	AddUser(chatID domain.ID, userID domain.ID) error
-//	RemoveUser(chatID domain.ID, userID domain.ID) error
-//	GetMembers(chatID domain.ID) ([]domain.ID, error)
-//}
-
+// ValidateChat checks a chat before it is saved and reports every invalid
+// field at once.
 func ValidateChat(chat domain.Chat) error {
-	if chat.ID == "" {
-		return fmt.Errorf("%w: Chat.ID", repositories.ErrMissingChatParameter)
-	}
-	if chat.Name == "" {
-		return fmt.Errorf("%w: Chat.Name", repositories.ErrMissingChatParameter)
-	}
-	if chat.Owner == "" {
-		return fmt.Errorf("%w: Chat.Owner", repositories.ErrMissingChatParameter)
-	}
-	if chat.Members == nil {
-		return fmt.Errorf("%w: Chat.Members", repositories.ErrMissingChatParameter)
-	}
-	if chat.CreatedTime == nil {
-		return fmt.Errorf("%w: Chat.CreatedTime", repositories.ErrMissingChatParameter)
+	var v domain.Validator
+	v.Required("id", string(chat.ID))
+	v.ChatName("name", chat.Name)
+	v.Text("description", chat.Description, maxChatDescriptionLength)
+	v.Text("topic", chat.Topic, maxChatTopicLength)
+	v.Required("owner", string(chat.Owner))
+	v.Check(chat.Members != nil, "members", "is required")
+	v.Check(chat.CreatedTime != nil, "created_time", "is required")
+	v.Check(chat.ChatType >= domain.Private && chat.ChatType <= domain.Channel, "chat_type", "is required")
+	return v.Err()
+}
+
+func validatePrivateChat(chat domain.Chat) error {
+	if len(chat.Members) != 2 || chat.Members[0] == chat.Members[1] {
+		return domain.InvalidArgument("a private chat must have exactly two members")
 	}
-	if chat.ChatType == 0 {
-		return fmt.Errorf("%w: Chat.ChatType", repositories.ErrMissingChatParameter)
+	if len(chat.Admins) != 0 {
+		return domain.InvalidArgument("a private chat cannot have admins")
 	}
 	return nil
 }
 
 func NewChatService(chat repositories.ChatRepository) *ChatService {
-	return &ChatService{chat}
+	return &ChatService{
+		Chat:                chat,
+		DeletionGracePeriod: DefaultDeletionGracePeriod,
+	}
+}
+
+// FindChat returns the chat. Soft-deleted chats are reported as not found.
+func (cs *ChatService) FindChat(ctx context.Context, chatID domain.ID) (domain.Chat, error) {
+	chat, err := cs.findChat(ctx, chatID)
+	if err != nil {
+		return domain.Chat{}, err
+	}
+	if chat.DeletedTime != nil {
+		return domain.Chat{}, domain.Wrap(repositories.ErrChatNotFound, "chat doesn't exist")
+	}
+	return chat, nil
 }
 
-func (cs *ChatService) FindChat(chatID domain.ID) (domain.Chat, error) {
-	chat, err := cs.Chat.FindChat(chatID)
+func (cs *ChatService) findChat(ctx context.Context, chatID domain.ID) (domain.Chat, error) {
+	chat, err := cs.Chat.FindChat(ctx, chatID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrChatNotFound) {
-			return domain.Chat{}, fmt.Errorf(" chat doesn't exist: %w", repositories.ErrChatNotFound)
+			return domain.Chat{}, domain.Wrap(repositories.ErrChatNotFound, "chat doesn't exist")
 		}
-		return domain.Chat{}, fmt.Errorf(" failed to find the chat: %v", err)
+		return domain.Chat{}, domain.Wrap(err, " failed to find the chat")
 	}
 
 	err = ValidateChat(chat)
@@ -65,69 +79,245 @@ func (cs *ChatService) FindChat(chatID domain.ID) (domain.Chat, error) {
 	return chat, nil
 }
 
-func (cs *ChatService) CreateChat(chat domain.Chat) (domain.ID, error) {
+// FindPrivateChat returns the private chat between the two users, or an
+// error wrapping ErrChatNotFound if they have none yet or it was deleted.
+func (cs *ChatService) FindPrivateChat(ctx context.Context, userID, otherUserID domain.ID) (domain.Chat, error) {
+	if userID == "" || otherUserID == "" {
+		return domain.Chat{}, domain.InvalidArgument("missing user id")
+	}
+	chat, err := cs.Chat.FindPrivateChat(ctx, userID, otherUserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChatNotFound) {
+			return domain.Chat{}, domain.Wrap(repositories.ErrChatNotFound, "private chat doesn't exist")
+		}
+		return domain.Chat{}, domain.Wrap(err, "failed to find private chat")
+	}
+	if chat.DeletedTime != nil {
+		return domain.Chat{}, domain.Wrap(repositories.ErrChatNotFound, "private chat doesn't exist")
+	}
+	return chat, nil
+}
+
+func (cs *ChatService) CreateChat(ctx context.Context, chat domain.Chat) (domain.ID, error) {
 	if err := ValidateChat(chat); err != nil {
 		return "", err
 	}
 
-	chatID, err := cs.Chat.CreateChat(chat)
+	if chat.ChatType == domain.Private {
+		if err := validatePrivateChat(chat); err != nil {
+			return "", err
+		}
+		_, err := cs.FindPrivateChat(ctx, chat.Members[0], chat.Members[1])
+		if err == nil {
+			return "", domain.Wrap(repositories.ErrDuplicateChat, "private chat already exists")
+		}
+		if !errors.Is(err, repositories.ErrChatNotFound) {
+			return "", err
+		}
+	}
+
+	chatID, err := cs.Chat.CreateChat(ctx, chat)
 	if err != nil {
 
 		if errors.Is(err, repositories.ErrDuplicateChat) {
-			return "", fmt.Errorf("chat already exists: %w", err)
+			return "", domain.Wrap(err, "chat already exists")
 		}
 
-		return "", fmt.Errorf("falied to create Chat: %w", err)
+		return "", domain.Wrap(err, "failed to create Chat")
 	}
 
 	return chatID, nil
 
 }
 
-func (cs *ChatService) UpdateChatName(UpdateChat domain.Chat) error {
-	if err := ValidateChat(UpdateChat); err != nil {
-		return err
-	}
+const (
+	maxChatDescriptionLength = 1024
+	maxChatTopicLength       = 256
+)
 
-	chat, err := cs.Chat.FindChat(UpdateChat.ID)
+// UpdateChat applies the non-nil fields of update to the chat and saves it.
+// Authorization of each field is the caller's job.
+func (cs *ChatService) UpdateChat(ctx context.Context, chatID domain.ID, update domain.ChatUpdate) (domain.Chat, error) {
+	chat, err := cs.FindChat(ctx, chatID)
 	if err != nil {
-		if errors.Is(err, repositories.ErrChatNotFound) {
-			return fmt.Errorf("chat for update not found: %w", repositories.ErrChatNotFound)
+		return domain.Chat{}, err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.Chat{}, domain.InvalidArgument("private chats cannot be changed")
+	}
+
+	var v domain.Validator
+	if update.Avatar != nil {
+		v.Check(strings.HasPrefix(update.Avatar.ContentType, "image/"), "avatar", "must be an image")
+	}
+	if update.History != nil {
+		switch *update.History {
+		case domain.HistoryFull, domain.HistorySinceJoined, domain.HistoryLastN:
+		default:
+			v.Check(false, "settings.history", "invalid value %v", *update.History)
 		}
-		return fmt.Errorf("falied to update Chat: %v", err)
+	}
+	if update.HistoryLastN != nil {
+		v.Check(*update.HistoryLastN >= 0, "settings.history_last_n", "cannot be negative")
+	}
+	if update.SlowMode != nil {
+		v.Check(*update.SlowMode >= 0, "settings.slow_mode", "cannot be negative")
+	}
+	if err = v.Err(); err != nil {
+		return domain.Chat{}, err
 	}
 
-	chat.Name = UpdateChat.Name
-	chat.Owner = UpdateChat.Owner
+	if update.Name != nil {
+		chat.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Description != nil {
+		chat.Description = *update.Description
+	}
+	if update.Topic != nil {
+		chat.Topic = *update.Topic
+	}
+	if update.Avatar != nil {
+		chat.Avatar = update.Avatar
+	}
+	if update.RemoveAvatar {
+		chat.Avatar = nil
+	}
+	if update.History != nil {
+		chat.Settings.History = *update.History
+	}
+	if update.HistoryLastN != nil {
+		chat.Settings.HistoryLastN = *update.HistoryLastN
+	}
+	if update.JoinApproval != nil {
+		chat.Settings.JoinApproval = *update.JoinApproval
+	}
+	if update.SlowMode != nil {
+		chat.Settings.SlowMode = *update.SlowMode
+	}
+	if update.MembersCanAdd != nil {
+		chat.Permissions = withRolePermission(chat, domain.Normal, domain.PermAddMembers, *update.MembersCanAdd)
+	}
+
+	if err = ValidateChat(chat); err != nil {
+		return domain.Chat{}, err
+	}
+
+	if err = cs.Chat.UpdateChat(ctx, chat); err != nil {
+		return domain.Chat{}, domain.Wrap(err, "failed to update Chat")
+	}
+	return chat, nil
+}
+
+// withRolePermission returns a copy of the chat's permissions with one
+// permission of the role set, starting from the defaults if the role has
+// never been customised.
+func withRolePermission(chat domain.Chat, role string, permission domain.Permission, allowed bool) domain.ChatPermissions {
+	updated := chat.Permissions
+	updated.Roles = make(map[string]domain.Permissions, len(chat.Permissions.Roles)+1)
+	for r, granted := range chat.Permissions.Roles {
+		updated.Roles[r] = granted
+	}
+
+	current, ok := chat.Permissions.Roles[role]
+	if !ok {
+		current = domain.DefaultRolePermissions(chat.ChatType, role)
+	}
+	granted := make(domain.Permissions, len(current)+1)
+	for p, v := range current {
+		granted[p] = v
+	}
+	granted[permission] = allowed
+	updated.Roles[role] = granted
+
+	return updated
+}
+
+// DeleteChat soft-deletes the chat. It can be restored with RestoreChat
+// until DeletionGracePeriod has passed, after which PurgeChat removes it.
+func (cs *ChatService) DeleteChat(ctx context.Context, chatID domain.ID) error {
+	if chatID == "" {
+		return domain.InvalidArgument("missing chat id")
+	}
+	if _, err := cs.FindChat(ctx, chatID); err != nil {
+		return err
+	}
 
-	err = cs.Chat.UpdateChatName(chat)
+	now := time.Now()
+	err := cs.Chat.SetDeletedTime(ctx, chatID, &now)
 	if err != nil {
-		return fmt.Errorf("falied to update Chat: %v", err)
+		return domain.Wrap(err, "failed to delete Chat")
 	}
 	return nil
 }
 
-func (cs *ChatService) DeleteChat(chatID domain.ID) error {
+// RestoreChat undoes DeleteChat while the grace period is still running.
+func (cs *ChatService) RestoreChat(ctx context.Context, chatID domain.ID) (domain.Chat, error) {
 	if chatID == "" {
-		return fmt.Errorf("missing chat id")
+		return domain.Chat{}, domain.InvalidArgument("missing chat id")
 	}
-	//_, err := cs.Chat.FindChat(chatID)
-	//if err != nil {
-	//	if errors.Is(err, repositories.ErrChatNotFound) {
-	//		return fmt.Errorf("chat for delete not found: %w", repositories.ErrChatNotFound)
-	//	}
-	//	return fmt.Errorf("failed to delete Chat: %v", err)
-	//}
-	err := cs.Chat.DeleteChat(chatID)
+	chat, err := cs.findChat(ctx, chatID)
+	if err != nil {
+		return domain.Chat{}, err
+	}
+	if chat.DeletedTime == nil {
+		return domain.Chat{}, domain.Conflict("chat is not deleted")
+	}
+	if time.Since(*chat.DeletedTime) > cs.DeletionGracePeriod {
+		return domain.Chat{}, domain.Wrap(repositories.ErrChatNotFound, "chat can no longer be restored")
+	}
+	if chat.ChatType == domain.Private && len(chat.Members) == 2 {
+		// The pair may have started a new private chat since.
+		_, err = cs.FindPrivateChat(ctx, chat.Members[0], chat.Members[1])
+		if err == nil {
+			return domain.Chat{}, domain.Conflict("the users already have another private chat")
+		}
+		if !errors.Is(err, repositories.ErrChatNotFound) {
+			return domain.Chat{}, err
+		}
+	}
+
+	if err = cs.Chat.SetDeletedTime(ctx, chatID, nil); err != nil {
+		return domain.Chat{}, domain.Wrap(err, "failed to restore chat")
+	}
+	chat.DeletedTime = nil
+	return chat, nil
+}
+
+// FindDeletedChat returns a soft-deleted chat, e.g. for its owner to restore.
+func (cs *ChatService) FindDeletedChat(ctx context.Context, chatID domain.ID) (domain.Chat, error) {
+	chat, err := cs.findChat(ctx, chatID)
+	if err != nil {
+		return domain.Chat{}, err
+	}
+	if chat.DeletedTime == nil {
+		return domain.Chat{}, domain.Conflict("chat is not deleted")
+	}
+	return chat, nil
+}
+
+// ListExpiredDeletedChats returns soft-deleted chats past the grace period.
+func (cs *ChatService) ListExpiredDeletedChats(ctx context.Context) ([]domain.Chat, error) {
+	chats, err := cs.Chat.ListDeletedChats(ctx, time.Now().Add(-cs.DeletionGracePeriod))
 	if err != nil {
-		return fmt.Errorf("falied to delete Chat: %v", err)
+		return nil, domain.Wrap(err, "failed to list deleted chats")
+	}
+	return chats, nil
+}
+
+// PurgeChat permanently removes the chat record.
+func (cs *ChatService) PurgeChat(ctx context.Context, chatID domain.ID) error {
+	if chatID == "" {
+		return domain.InvalidArgument("missing chat id")
+	}
+	if err := cs.Chat.DeleteChat(ctx, chatID); err != nil {
+		return domain.Wrap(err, "failed to purge Chat")
 	}
 	return nil
 }
 
-func (cs *ChatService) GetMessages(chatID domain.ID) ([]domain.Message, error) {
+func (cs *ChatService) GetMessages(ctx context.Context, chatID domain.ID) ([]domain.Message, error) {
 	if chatID == "" {
-		return nil, fmt.Errorf("missing chat id")
+		return nil, domain.InvalidArgument("missing chat id")
 	}
 	//chat, err := cs.Chat.FindChat(chatID)
 	//if err != nil {
@@ -137,19 +327,45 @@ func (cs *ChatService) GetMessages(chatID domain.ID) ([]domain.Message, error) {
 	//	return nil, fmt.Errorf("failed to find chat to get messages: %v", err)
 	//}
 
-	messages, err := cs.Chat.GetMessages(chatID)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	messages, err := cs.Chat.GetMessages(ctx, chatID)
 	if err != nil {
-		return nil, fmt.Errorf("falied to get messages: %v", err)
+		return nil, domain.Wrap(err, "failed to get messages")
 	}
 	return messages, nil
 }
 
-func (cs *ChatService) AddUser(chatID domain.ID, userIDs []domain.ID) error {
+// GetVisibleMessages returns the chat's messages the user is allowed to
+// read under the chat's history visibility setting.
+func (cs *ChatService) GetVisibleMessages(ctx context.Context, chat domain.Chat, userID domain.ID) ([]domain.Message, error) {
+	messages, err := cs.GetMessages(ctx, chat.ID)
+	if err != nil {
+		return nil, err
+	}
+	return chat.VisibleMessages(userID, messages), nil
+}
+
+// HistoryCutoff returns the creation time of the oldest message of the chat
+// the user may read, or nil if there is no limit.
+func (cs *ChatService) HistoryCutoff(ctx context.Context, chat domain.Chat, userID domain.ID) (*time.Time, error) {
+	var messages []domain.Message
+	if chat.Settings.History == domain.HistoryLastN {
+		var err error
+		if messages, err = cs.GetMessages(ctx, chat.ID); err != nil {
+			return nil, err
+		}
+	}
+	return chat.HistoryCutoff(userID, messages), nil
+}
+
+func (cs *ChatService) AddUser(ctx context.Context, chatID domain.ID, userIDs []domain.ID) error {
 	if chatID == "" {
-		return fmt.Errorf("missing chat id")
+		return domain.InvalidArgument("missing chat id")
 	}
 	if userIDs == nil {
-		return fmt.Errorf("missing user ids")
+		return domain.InvalidArgument("missing user ids")
 	}
 	//chat, err := cs.Chat.FindChat(chatID)
 	//if err != nil {
@@ -167,20 +383,28 @@ func (cs *ChatService) AddUser(chatID domain.ID, userIDs []domain.ID) error {
 	//	}
 	//}
 
-	err := cs.Chat.AddUser(chatID, userIDs)
+	chat, err := cs.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("cannot add users to a private chat")
+	}
+
+	err = cs.Chat.AddUser(ctx, chatID, userIDs)
 	if err != nil {
-		return fmt.Errorf("falied to add user to chat: %v", err)
+		return domain.Wrap(err, "failed to add user to chat")
 	}
 
 	return nil
 }
 
-func (cs *ChatService) RemoveUser(chatID domain.ID, userIDs []domain.ID) error {
+func (cs *ChatService) RemoveUser(ctx context.Context, chatID domain.ID, userIDs []domain.ID) error {
 	if chatID == "" {
-		return fmt.Errorf("missing chat id")
+		return domain.InvalidArgument("missing chat id")
 	}
 	if userIDs == nil {
-		return fmt.Errorf("missing user ids")
+		return domain.InvalidArgument("missing user ids")
 	}
 	//_, err := cs.Chat.FindChat(chatID)
 	//if err != nil {
@@ -190,16 +414,24 @@ func (cs *ChatService) RemoveUser(chatID domain.ID, userIDs []domain.ID) error {
 	//	return fmt.Errorf("failed to find chat to remove user: %v", err)
 	//}
 
-	err := cs.Chat.RemoveUser(chatID, userIDs)
+	chat, err := cs.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("cannot remove users from a private chat")
+	}
+
+	err = cs.Chat.RemoveUser(ctx, chatID, userIDs)
 	if err != nil {
-		return fmt.Errorf("falied to remove users from chat: %v", err)
+		return domain.Wrap(err, "failed to remove users from chat")
 	}
 	return nil
 }
 
-func (cs *ChatService) GetMembers(chatID domain.ID) ([]domain.ID, error) {
+func (cs *ChatService) GetMembers(ctx context.Context, chatID domain.ID) ([]domain.ID, error) {
 	if chatID == "" {
-		return nil, fmt.Errorf("missing chat id")
+		return nil, domain.InvalidArgument("missing chat id")
 	}
 	//chat, err := cs.Chat.FindChat(chatID)
 	//if err != nil {
@@ -209,29 +441,151 @@ func (cs *ChatService) GetMembers(chatID domain.ID) ([]domain.ID, error) {
 	//	return nil, fmt.Errorf("failed to find chat to get members: %v", err)
 	//}
 
-	members, err := cs.Chat.GetMembers(chatID)
+	members, err := cs.Chat.GetMembers(ctx, chatID)
 	if err != nil {
-		return nil, fmt.Errorf("falied to get members: %v", err)
+		return nil, domain.Wrap(err, "failed to get members")
 	}
 
 	return members, nil
 }
-func (cs *ChatService) SetAdmin(userID, chatID domain.ID) error {
+
+func (cs *ChatService) SubscriberCount(ctx context.Context, chatID domain.ID) (int, error) {
+	members, err := cs.GetMembers(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+	return len(members), nil
+}
+
+func (cs *ChatService) SetAdmin(ctx context.Context, userID, chatID domain.ID) error {
 	if userID == "" {
-		return fmt.Errorf("user ID is empty")
+		return domain.InvalidArgument("user ID is empty")
 	}
 	if chatID == "" {
-		return fmt.Errorf("chat ID is empty")
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	chat, err := cs.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("private chats cannot have admins")
 	}
-	err := cs.Chat.SetAdmin(userID, chatID)
+	err = cs.Chat.SetAdmin(ctx, userID, chatID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrUserNotFound) {
-			return fmt.Errorf("user  not found")
+			return domain.Wrap(err, "user not found")
 		}
 		if errors.Is(err, repositories.ErrChatNotFound) {
-			return fmt.Errorf("chat  not found")
+			return domain.Wrap(err, "chat not found")
 		}
-		return fmt.Errorf("failed to set admin: %v", err)
+		return domain.Wrap(err, "failed to set admin")
+	}
+	return nil
+}
+
+func (cs *ChatService) SetOwner(ctx context.Context, userID, chatID domain.ID) error {
+	if userID == "" {
+		return domain.InvalidArgument("user ID is empty")
+	}
+	if chatID == "" {
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	chat, err := cs.FindChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if chat.ChatType == domain.Private {
+		return domain.InvalidArgument("private chats cannot change owner")
+	}
+	if !chat.IsMember(userID) {
+		return domain.InvalidArgument("new owner must be a member of the chat")
+	}
+	err = cs.Chat.SetOwner(ctx, userID, chatID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChatNotFound) {
+			return domain.Wrap(err, "chat not found")
+		}
+		return domain.Wrap(err, "failed to set owner")
+	}
+	return nil
+}
+
+func (cs *ChatService) RemoveAdmin(ctx context.Context, userID, chatID domain.ID) error {
+	if userID == "" {
+		return domain.InvalidArgument("user ID is empty")
+	}
+	if chatID == "" {
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	err := cs.Chat.RemoveAdmin(ctx, userID, chatID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return domain.Wrap(err, "user not found")
+		}
+		if errors.Is(err, repositories.ErrChatNotFound) {
+			return domain.Wrap(err, "chat not found")
+		}
+		return domain.Wrap(err, "failed to remove admin")
+	}
+	return nil
+}
+
+func (cs *ChatService) UpdatePermissions(ctx context.Context, chatID domain.ID, permissions domain.ChatPermissions) error {
+	if chatID == "" {
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	for _, granted := range permissions.Roles {
+		if err := validatePermissions(granted); err != nil {
+			return err
+		}
+	}
+	for _, granted := range permissions.Overrides {
+		if err := validatePermissions(granted); err != nil {
+			return err
+		}
+	}
+	if _, ok := permissions.Roles[domain.Owner]; ok {
+		return domain.InvalidArgument("owner permissions cannot be changed")
+	}
+
+	if err := cs.Chat.UpdatePermissions(ctx, chatID, permissions); err != nil {
+		return domain.Wrap(err, "failed to update permissions")
+	}
+	return nil
+}
+
+func validatePermissions(permissions domain.Permissions) error {
+	for permission := range permissions {
+		if domain.IsOwnerOnly(permission) {
+			return domain.InvalidArgument("permission %s is reserved for the owner", permission)
+		}
+	}
+	return nil
+}
+
+func (cs *ChatService) PinMessage(ctx context.Context, chatID, messageID domain.ID) error {
+	if chatID == "" {
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	if messageID == "" {
+		return domain.InvalidArgument("message ID is empty")
+	}
+	if err := cs.Chat.PinMessage(ctx, chatID, messageID); err != nil {
+		return domain.Wrap(err, "failed to pin message")
+	}
+	return nil
+}
+
+func (cs *ChatService) UnpinMessage(ctx context.Context, chatID, messageID domain.ID) error {
+	if chatID == "" {
+		return domain.InvalidArgument("chat ID is empty")
+	}
+	if messageID == "" {
+		return domain.InvalidArgument("message ID is empty")
+	}
+	if err := cs.Chat.UnpinMessage(ctx, chatID, messageID); err != nil {
+		return domain.Wrap(err, "failed to unpin message")
 	}
 	return nil
 }
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ContactService struct {
+	Contact repositories.ContactRepository
+	// RequireAcceptance makes adding a contact send a request; once accepted
+	// both users appear in each other's contacts.
+	RequireAcceptance bool
+}
+
+func NewContactService(contact repositories.ContactRepository, requireAcceptance bool) *ContactService {
+	return &ContactService{
+		Contact:           contact,
+		RequireAcceptance: requireAcceptance,
+	}
+}
+
+// AddContact adds contactID to the user's contacts, or sends a contact
+// request when acceptance is required, in which case pending is true. A
+// request that crosses one from the other user is accepted straight away.
+func (cs *ContactService) AddContact(ctx context.Context, user domain.User, contactID domain.ID) (pending bool, err error) {
+	if contactID == "" {
+		return false, domain.InvalidArgument("contact ID is required")
+	}
+	if contactID == user.ID {
+		return false, domain.InvalidArgument("cannot add yourself as a contact")
+	}
+	for _, existing := range user.Contacts {
+		if existing == contactID {
+			return false, domain.Conflict("user is already a contact")
+		}
+	}
+
+	if !cs.RequireAcceptance {
+		if err = cs.Contact.AddContact(ctx, user.ID, contactID); err != nil {
+			return false, domain.Wrap(err, "failed to add contact")
+		}
+		return false, nil
+	}
+
+	incoming, err := cs.findRequest(ctx, contactID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if incoming != nil {
+		return false, cs.accept(ctx, *incoming)
+	}
+
+	outgoing, err := cs.findRequest(ctx, user.ID, contactID)
+	if err != nil {
+		return false, err
+	}
+	if outgoing != nil {
+		return true, nil
+	}
+
+	now := time.Now()
+	request := domain.ContactRequest{
+		ID:          domain.ID(uuid.New().String()),
+		FromUserID:  user.ID,
+		ToUserID:    contactID,
+		CreatedTime: &now,
+	}
+	if err = cs.Contact.CreateContactRequest(ctx, request); err != nil {
+		return false, domain.Wrap(err, "failed to create contact request")
+	}
+	return true, nil
+}
+
+// RemoveContact removes the contact from the user's list, and when
+// acceptance is required, the user from the contact's list as well.
+func (cs *ContactService) RemoveContact(ctx context.Context, userID, contactID domain.ID) error {
+	if contactID == "" {
+		return domain.InvalidArgument("contact ID is required")
+	}
+	if err := cs.Contact.RemoveContact(ctx, userID, contactID); err != nil {
+		return domain.Wrap(err, "failed to remove contact")
+	}
+	if cs.RequireAcceptance {
+		if err := cs.Contact.RemoveContact(ctx, contactID, userID); err != nil {
+			return domain.Wrap(err, "failed to remove contact")
+		}
+	}
+	return nil
+}
+
+// DeleteUserContacts removes every contact and contact request involving
+// the user, e.g. when their account is anonymized.
+func (cs *ContactService) DeleteUserContacts(ctx context.Context, userID domain.ID) error {
+	if userID == "" {
+		return domain.InvalidArgument("user ID is required")
+	}
+	if err := cs.Contact.DeleteUserContacts(ctx, userID); err != nil {
+		return domain.Wrap(err, "failed to delete contacts")
+	}
+	return nil
+}
+
+func (cs *ContactService) ListContactRequests(ctx context.Context, userID domain.ID) ([]domain.ContactRequest, error) {
+	if userID == "" {
+		return nil, domain.InvalidArgument("user ID is required")
+	}
+	requests, err := cs.Contact.ListContactRequests(ctx, userID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list contact requests")
+	}
+	return requests, nil
+}
+
+// RespondToRequest accepts or declines the request fromUserID sent to userID.
+func (cs *ContactService) RespondToRequest(ctx context.Context, userID, fromUserID domain.ID, accept bool) error {
+	request, err := cs.findRequest(ctx, fromUserID, userID)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		return repositories.ErrContactRequestNotFound
+	}
+
+	if accept {
+		return cs.accept(ctx, *request)
+	}
+	if err = cs.Contact.DeleteContactRequest(ctx, request.ID); err != nil {
+		return domain.Wrap(err, "failed to decline contact request")
+	}
+	return nil
+}
+
+func (cs *ContactService) accept(ctx context.Context, request domain.ContactRequest) error {
+	if err := cs.Contact.AddContact(ctx, request.FromUserID, request.ToUserID); err != nil {
+		return domain.Wrap(err, "failed to accept contact request")
+	}
+	if err := cs.Contact.AddContact(ctx, request.ToUserID, request.FromUserID); err != nil {
+		return domain.Wrap(err, "failed to accept contact request")
+	}
+	if err := cs.Contact.DeleteContactRequest(ctx, request.ID); err != nil {
+		return domain.Wrap(err, "failed to accept contact request")
+	}
+	return nil
+}
+
+// findRequest returns the pending request between the users, or nil.
+func (cs *ContactService) findRequest(ctx context.Context, fromUserID, toUserID domain.ID) (*domain.ContactRequest, error) {
+	request, err := cs.Contact.FindContactRequest(ctx, fromUserID, toUserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrContactRequestNotFound) {
+			return nil, nil
+		}
+		return nil, domain.Wrap(err, "failed to find contact request")
+	}
+	return &request, nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultExportTTL is how long a finished export can be downloaded.
+const DefaultExportTTL = 7 * 24 * time.Hour
+
+type ExportService struct {
+	Export repositories.ExportRepository
+	TTL    time.Duration
+}
+
+func NewExportService(export repositories.ExportRepository) *ExportService {
+	return &ExportService{
+		Export: export,
+		TTL:    DefaultExportTTL,
+	}
+}
+
+// ExportSection is one JSON file of an export archive.
+type ExportSection struct {
+	Name        string // file name inside the archive, e.g. "profile.json"
+	Description string
+	Entries     int
+	Data        any
+}
+
+// CreateExport records a pending export for the user. The archive is built
+// later by whoever processes ListPendingExports.
+func (es *ExportService) CreateExport(ctx context.Context, userID domain.ID) (domain.DataExport, error) {
+	if userID == "" {
+		return domain.DataExport{}, domain.InvalidArgument("userID cannot be empty")
+	}
+	token, err := newRandomToken()
+	if err != nil {
+		return domain.DataExport{}, domain.Wrap(err, "failed to generate export token")
+	}
+
+	now := time.Now()
+	export := domain.DataExport{
+		ID:          domain.ID(uuid.New().String()),
+		UserID:      userID,
+		Token:       token,
+		Status:      domain.ExportPending,
+		CreatedTime: &now,
+	}
+	if err = es.Export.CreateExport(ctx, export); err != nil {
+		return domain.DataExport{}, domain.Wrap(err, "failed to create export")
+	}
+	return export, nil
+}
+
+// FindExport returns the user's export. Exports of other users are reported
+// as not found.
+func (es *ExportService) FindExport(ctx context.Context, exportID, userID domain.ID) (domain.DataExport, error) {
+	if exportID == "" {
+		return domain.DataExport{}, domain.InvalidArgument("exportID cannot be empty")
+	}
+	export, err := es.Export.FindExport(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrExportNotFound) {
+			return domain.DataExport{}, domain.Wrap(repositories.ErrExportNotFound, "export doesn't exist")
+		}
+		return domain.DataExport{}, domain.Wrap(err, "failed to find export")
+	}
+	if export.UserID != userID {
+		return domain.DataExport{}, domain.Wrap(repositories.ErrExportNotFound, "export doesn't exist")
+	}
+	return export, nil
+}
+
+func (es *ExportService) ListPendingExports(ctx context.Context) ([]domain.DataExport, error) {
+	exports, err := es.Export.ListPendingExports(ctx)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list pending exports")
+	}
+	return exports, nil
+}
+
+// Complete zips the sections, together with a manifest, stores the archive
+// and marks the export as ready until TTL has passed.
+func (es *ExportService) Complete(ctx context.Context, export domain.DataExport, sections []ExportSection) (domain.DataExport, error) {
+	now := time.Now()
+	manifest := domain.ExportManifest{
+		ExportID:      export.ID,
+		UserID:        export.UserID,
+		GeneratedTime: now,
+	}
+	for _, section := range sections {
+		manifest.Files = append(manifest.Files, domain.ExportFile{
+			Name:        section.Name,
+			Description: section.Description,
+			Entries:     section.Entries,
+		})
+	}
+
+	archive, err := buildArchive(manifest, sections)
+	if err != nil {
+		return domain.DataExport{}, domain.Wrap(err, "failed to build export archive")
+	}
+	if err = es.Export.SaveArchive(ctx, export.ID, archive); err != nil {
+		return domain.DataExport{}, domain.Wrap(err, "failed to save export archive")
+	}
+
+	expires := now.Add(es.TTL)
+	export.Status = domain.ExportReady
+	export.ReadyTime = &now
+	export.ExpiresTime = &expires
+	if err = es.Export.UpdateExport(ctx, export); err != nil {
+		return domain.DataExport{}, domain.Wrap(err, "failed to update export")
+	}
+	return export, nil
+}
+
+func (es *ExportService) Fail(ctx context.Context, export domain.DataExport, cause error) error {
+	export.Status = domain.ExportFailed
+	export.Error = cause.Error()
+	if err := es.Export.UpdateExport(ctx, export); err != nil {
+		return domain.Wrap(err, "failed to update export")
+	}
+	return nil
+}
+
+// Download returns the archive if the token matches and the export has not
+// been downloaded or expired yet. The export is marked downloaded before the
+// archive is read, so concurrent downloads cannot both succeed. The archive
+// is deleted afterwards, or by PurgeExpired if that fails.
+func (es *ExportService) Download(ctx context.Context, exportID, userID domain.ID, token string) ([]byte, error) {
+	export, err := es.FindExport(ctx, exportID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(export.Token), []byte(token)) != 1 {
+		return nil, domain.Wrap(repositories.ErrExportNotFound, "export doesn't exist")
+	}
+	now := time.Now()
+	if !export.IsDownloadable(now) {
+		return nil, repositories.ErrExportNotAvailable
+	}
+
+	if err = es.Export.MarkExportDownloaded(ctx, exportID, now); err != nil {
+		if errors.Is(err, repositories.ErrExportNotAvailable) {
+			return nil, err
+		}
+		return nil, domain.Wrap(err, "failed to update export")
+	}
+	archive, err := es.Export.LoadArchive(ctx, exportID)
+	if err != nil {
+		// Give the download back; the export itself is unchanged.
+		_ = es.Export.UpdateExport(ctx, export)
+		return nil, domain.Wrap(err, "failed to load export archive")
+	}
+	_ = es.Export.DeleteArchive(ctx, exportID)
+	return archive, nil
+}
+
+// PurgeExpired deletes the archives of exports that expired, or that were
+// downloaded but whose archive could not be deleted then, and returns how
+// many were removed.
+func (es *ExportService) PurgeExpired(ctx context.Context) (int, error) {
+	exports, err := es.Export.ListExpiredExports(ctx, time.Now())
+	if err != nil {
+		return 0, domain.Wrap(err, "failed to list expired exports")
+	}
+	for i, export := range exports {
+		if err = es.Export.DeleteArchive(ctx, export.ID); err != nil {
+			return i, domain.Wrap(err, "failed to delete export archive")
+		}
+	}
+	return len(exports), nil
+}
+
+func buildArchive(manifest domain.ExportManifest, sections []ExportSection) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	write := func(name string, data any) error {
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return domain.Wrap(err, "%s", name)
+		}
+		f, err := w.Create(name)
+		if err != nil {
+			return domain.Wrap(err, "%s", name)
+		}
+		_, err = f.Write(content)
+		return err
+	}
+
+	if err := write("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	for _, section := range sections {
+		if err := write(section.Name, section.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
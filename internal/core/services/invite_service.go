@@ -0,0 +1,220 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InviteService struct {
+	Invite repositories.InviteRepository
+}
+
+func NewInviteService(invite repositories.InviteRepository) *InviteService {
+	return &InviteService{Invite: invite}
+}
+
+// ValidateInviteOptions checks the lifetime and usage limit of a new invite.
+func ValidateInviteOptions(ttl time.Duration, usageLimit int) error {
+	var v domain.Validator
+	v.Check(ttl >= 0, "ttl", "cannot be negative")
+	v.Check(usageLimit >= 0, "usage_limit", "cannot be negative")
+	return v.Err()
+}
+
+// CreateInvite creates an invite link for the chat. A zero ttl never expires
+// and a zero usageLimit allows unlimited joins.
+func (is *InviteService) CreateInvite(ctx context.Context, chatID, createdBy domain.ID, ttl time.Duration, usageLimit int) (domain.Invite, error) {
+	if chatID == "" {
+		return domain.Invite{}, domain.InvalidArgument("chatID cannot be empty")
+	}
+	if createdBy == "" {
+		return domain.Invite{}, domain.InvalidArgument("createdBy cannot be empty")
+	}
+	if err := ValidateInviteOptions(ttl, usageLimit); err != nil {
+		return domain.Invite{}, err
+	}
+
+	code, err := newRandomToken()
+	if err != nil {
+		return domain.Invite{}, domain.Wrap(err, "failed to generate invite code")
+	}
+
+	now := time.Now()
+	invite := domain.Invite{
+		Code:        code,
+		ChatID:      chatID,
+		CreatedBy:   createdBy,
+		UsageLimit:  usageLimit,
+		CreatedTime: &now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		invite.ExpiresTime = &expires
+	}
+
+	if err = is.Invite.CreateInvite(ctx, invite); err != nil {
+		return domain.Invite{}, domain.Wrap(err, "failed to create invite")
+	}
+	return invite, nil
+}
+
+func (is *InviteService) FindInvite(ctx context.Context, code string) (domain.Invite, error) {
+	if code == "" {
+		return domain.Invite{}, domain.InvalidArgument("invite code cannot be empty")
+	}
+	invite, err := is.Invite.FindInvite(ctx, code)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInviteNotFound) {
+			return domain.Invite{}, domain.Wrap(repositories.ErrInviteNotFound, "invite doesn't exist")
+		}
+		return domain.Invite{}, domain.Wrap(err, "failed to find invite")
+	}
+	return invite, nil
+}
+
+func (is *InviteService) ListInvites(ctx context.Context, chatID domain.ID) ([]domain.Invite, error) {
+	if chatID == "" {
+		return nil, domain.InvalidArgument("chatID cannot be empty")
+	}
+	invites, err := is.Invite.ListInvites(ctx, chatID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list invites")
+	}
+	return invites, nil
+}
+
+func (is *InviteService) RevokeInvite(ctx context.Context, code string) error {
+	invite, err := is.FindInvite(ctx, code)
+	if err != nil {
+		return err
+	}
+	invite.Revoked = true
+	if err = is.Invite.UpdateInvite(ctx, invite); err != nil {
+		return domain.Wrap(err, "failed to revoke invite")
+	}
+	return nil
+}
+
+// UseInvite consumes one use of the invite. Callers should run every other
+// check first, so that rejected joins do not use the invite up.
+func (is *InviteService) UseInvite(ctx context.Context, code string) (domain.Invite, error) {
+	if code == "" {
+		return domain.Invite{}, domain.InvalidArgument("invite code cannot be empty")
+	}
+	invite, err := is.Invite.IncrementInviteUsage(ctx, code, time.Now())
+	if err != nil {
+		if errors.Is(err, repositories.ErrInviteNotFound) || errors.Is(err, repositories.ErrInviteNotUsable) {
+			return domain.Invite{}, err
+		}
+		return domain.Invite{}, domain.Wrap(err, "failed to use invite")
+	}
+	return invite, nil
+}
+
+// CheckCanRequestJoin fails with ErrJoinRequestPending if the user already
+// has a pending join request for the chat. It lets callers stop early;
+// CreateJoinRequest enforces the rule on its own.
+func (is *InviteService) CheckCanRequestJoin(ctx context.Context, chatID, userID domain.ID) error {
+	pending, err := is.ListPendingJoinRequests(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	for _, request := range pending {
+		if request.UserID == userID {
+			return repositories.ErrJoinRequestPending
+		}
+	}
+	return nil
+}
+
+// CreateJoinRequest files a pending request for the user to join the chat,
+// or fails with ErrJoinRequestPending if they already have one.
+func (is *InviteService) CreateJoinRequest(ctx context.Context, chatID, userID domain.ID, inviteCode string) (domain.JoinRequest, error) {
+	if chatID == "" {
+		return domain.JoinRequest{}, domain.InvalidArgument("chatID cannot be empty")
+	}
+	if userID == "" {
+		return domain.JoinRequest{}, domain.InvalidArgument("userID cannot be empty")
+	}
+
+	now := time.Now()
+	request := domain.JoinRequest{
+		ID:          domain.ID(uuid.New().String()),
+		ChatID:      chatID,
+		UserID:      userID,
+		InviteCode:  inviteCode,
+		Status:      domain.Pending,
+		CreatedTime: &now,
+	}
+	if err := is.Invite.CreateJoinRequest(ctx, request); err != nil {
+		if errors.Is(err, repositories.ErrJoinRequestPending) {
+			return domain.JoinRequest{}, err
+		}
+		return domain.JoinRequest{}, domain.Wrap(err, "failed to create join request")
+	}
+	return request, nil
+}
+
+func (is *InviteService) ListPendingJoinRequests(ctx context.Context, chatID domain.ID) ([]domain.JoinRequest, error) {
+	if chatID == "" {
+		return nil, domain.InvalidArgument("chatID cannot be empty")
+	}
+	requests, err := is.Invite.ListJoinRequests(ctx, chatID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list join requests")
+	}
+
+	var pending []domain.JoinRequest
+	for _, request := range requests {
+		if request.Status == domain.Pending {
+			pending = append(pending, request)
+		}
+	}
+	return pending, nil
+}
+
+// ResolveJoinRequest moves a pending request of the chat to Approved or Rejected.
+func (is *InviteService) ResolveJoinRequest(ctx context.Context, chatID, requestID domain.ID, status domain.JoinRequestStatus) (domain.JoinRequest, error) {
+	if requestID == "" {
+		return domain.JoinRequest{}, domain.InvalidArgument("requestID cannot be empty")
+	}
+	if status != domain.Approved && status != domain.Rejected {
+		return domain.JoinRequest{}, domain.InvalidArgument("invalid join request status: %v", status)
+	}
+
+	request, err := is.Invite.FindJoinRequest(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrJoinRequestNotFound) {
+			return domain.JoinRequest{}, domain.Wrap(repositories.ErrJoinRequestNotFound, "join request doesn't exist")
+		}
+		return domain.JoinRequest{}, domain.Wrap(err, "failed to find join request")
+	}
+	if request.ChatID != chatID {
+		return domain.JoinRequest{}, domain.Wrap(repositories.ErrJoinRequestNotFound, "join request doesn't exist")
+	}
+	if request.Status != domain.Pending {
+		return domain.JoinRequest{}, domain.Conflict("join request was already resolved")
+	}
+
+	request.Status = status
+	if err = is.Invite.UpdateJoinRequest(ctx, request); err != nil {
+		return domain.JoinRequest{}, domain.Wrap(err, "failed to update join request")
+	}
+	return request, nil
+}
+
+// newRandomToken returns an unguessable URL-safe token.
+func newRandomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/infrastructure/memory"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCreateJoinRequestConcurrent(t *testing.T) {
+	is := NewInviteService(memory.NewInviteRepository())
+	ctx := context.Background()
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := is.CreateJoinRequest(ctx, "chat", "user", "code")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var created int
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, repositories.ErrJoinRequestPending):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d join requests created, want 1", created)
+	}
+	if err := is.CheckCanRequestJoin(ctx, "chat", "user"); !errors.Is(err, repositories.ErrJoinRequestPending) {
+		t.Errorf("CheckCanRequestJoin = %v, want ErrJoinRequestPending", err)
+	}
+}
+
+func TestCreateJoinRequestAfterResolve(t *testing.T) {
+	is := NewInviteService(memory.NewInviteRepository())
+	ctx := context.Background()
+
+	request, err := is.CreateJoinRequest(ctx, "chat", "user", "code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = is.ResolveJoinRequest(ctx, "chat", request.ID, domain.Rejected); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = is.CreateJoinRequest(ctx, "chat", "user", "code"); err != nil {
+		t.Errorf("CreateJoinRequest after rejection: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginPolicy decides how failed logins for one kind of key are throttled.
+// After FreeAttempts failures each further attempt has to wait BaseDelay,
+// doubling with every failure up to MaxDelay, and at LockoutThreshold
+// failures the key is locked for LockoutDuration. Failures are forgotten
+// once none has happened for Window.
+type LoginPolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	Window           time.Duration
+}
+
+var (
+	DefaultUserLoginPolicy = LoginPolicy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  30 * time.Minute,
+		Window:           time.Hour,
+	}
+	// Many users can share an address, so IPs get more room.
+	DefaultIPLoginPolicy = LoginPolicy{
+		FreeAttempts:     10,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		LockoutThreshold: 50,
+		LockoutDuration:  30 * time.Minute,
+		Window:           time.Hour,
+	}
+)
+
+// LoginDelayError is returned while a username or IP has to wait before
+// trying to log in again. It matches repositories.ErrAccountLocked with
+// errors.Is when locked, and repositories.ErrTooManyAttempts otherwise.
+type LoginDelayError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginDelayError) Error() string {
+	return fmt.Sprintf("%v: retry in %s", e.sentinel(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginDelayError) Is(target error) bool {
+	return target == e.sentinel()
+}
+
+func (e *LoginDelayError) ErrorCode() domain.ErrorCode {
+	return domain.CodeRateLimited
+}
+
+func (e *LoginDelayError) sentinel() error {
+	if e.Locked {
+		return repositories.ErrAccountLocked
+	}
+	return repositories.ErrTooManyAttempts
+}
+
+// LoginGuardService tracks failed logins per username and per IP address.
+type LoginGuardService struct {
+	Attempts   repositories.LoginAttemptStore
+	UserPolicy LoginPolicy
+	IPPolicy   LoginPolicy
+}
+
+func NewLoginGuardService(attempts repositories.LoginAttemptStore) *LoginGuardService {
+	return &LoginGuardService{
+		Attempts:   attempts,
+		UserPolicy: DefaultUserLoginPolicy,
+		IPPolicy:   DefaultIPLoginPolicy,
+	}
+}
+
+// Check returns a *LoginDelayError if either the username or the IP may
+// not try to log in yet. An empty ip is not tracked.
+func (lg *LoginGuardService) Check(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	for key, policy := range lg.keys(username, ip) {
+		attempts, err := lg.Attempts.GetLoginAttempts(ctx, key)
+		if err != nil {
+			return domain.Wrap(err, "failed to check login attempts")
+		}
+
+		if attempts.LockedUntil != nil && now.Before(*attempts.LockedUntil) {
+			return &LoginDelayError{RetryAfter: attempts.LockedUntil.Sub(now), Locked: true}
+		}
+		if attempts.LastFailureTime == nil || now.Sub(*attempts.LastFailureTime) > policy.Window {
+			continue
+		}
+		if wait := attempts.LastFailureTime.Add(policy.delay(attempts.Failures)).Sub(now); wait > 0 {
+			return &LoginDelayError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login. It returns when the username's lock
+// ends if this failure locked it, so that the user can be told.
+func (lg *LoginGuardService) RecordFailure(ctx context.Context, username, ip string) (*time.Time, error) {
+	now := time.Now()
+	var lockedUntil *time.Time
+	for key, policy := range lg.keys(username, ip) {
+		attempts, err := lg.Attempts.AddLoginFailure(ctx, key, now, policy.Window)
+		if err != nil {
+			return nil, domain.Wrap(err, "failed to record login failure")
+		}
+		if attempts.Failures < policy.LockoutThreshold {
+			continue
+		}
+
+		until := now.Add(policy.LockoutDuration)
+		if err = lg.Attempts.LockLogin(ctx, key, until); err != nil {
+			return nil, domain.Wrap(err, "failed to lock login")
+		}
+		if key == userKey(username) {
+			lockedUntil = &until
+		}
+	}
+	return lockedUntil, nil
+}
+
+// RecordSuccess clears the username's failures. IP counters are left to
+// expire, so that logging into one account does not reset an attack on
+// others from the same address.
+func (lg *LoginGuardService) RecordSuccess(ctx context.Context, username string) error {
+	return lg.Unlock(ctx, username)
+}
+
+// Unlock clears the failures and any lock on the username.
+func (lg *LoginGuardService) Unlock(ctx context.Context, username string) error {
+	if strings.TrimSpace(username) == "" {
+		return domain.InvalidArgument("username is required")
+	}
+	if err := lg.Attempts.ResetLoginAttempts(ctx, userKey(username)); err != nil {
+		return domain.Wrap(err, "failed to reset login attempts")
+	}
+	return nil
+}
+
+func (lg *LoginGuardService) keys(username, ip string) map[string]LoginPolicy {
+	keys := map[string]LoginPolicy{userKey(username): lg.UserPolicy}
+	if ip != "" {
+		keys["ip:"+ip] = lg.IPPolicy
+	}
+	return keys
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// delay is how long to wait after the given number of failures.
+func (p LoginPolicy) delay(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/infrastructure/memory"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyDelay(t *testing.T) {
+	policy := LoginPolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{8, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultLoginPolicies(t *testing.T) {
+	for name, policy := range map[string]LoginPolicy{"user": DefaultUserLoginPolicy, "ip": DefaultIPLoginPolicy} {
+		if policy.LockoutThreshold <= policy.FreeAttempts {
+			t.Errorf("%s policy locks out before the free attempts are used", name)
+		}
+		if got := policy.delay(policy.LockoutThreshold); got <= 0 || got > policy.MaxDelay {
+			t.Errorf("%s policy delay at lockout = %v, want within (0, %v]", name, got, policy.MaxDelay)
+		}
+	}
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	guard := NewLoginGuardService(memory.NewLoginAttemptStore())
+	guard.UserPolicy = LoginPolicy{FreeAttempts: 2, LockoutThreshold: 3, LockoutDuration: time.Hour, Window: time.Hour}
+	guard.IPPolicy = LoginPolicy{FreeAttempts: 100, LockoutThreshold: 100, Window: time.Hour}
+
+	for i := 1; i <= 2; i++ {
+		lockedUntil, err := guard.RecordFailure(ctx, "Alice", "10.0.0.1")
+		if err != nil || lockedUntil != nil {
+			t.Fatalf("failure %d: locked until %v, err %v", i, lockedUntil, err)
+		}
+		if err = guard.Check(ctx, "alice", "10.0.0.1"); err != nil {
+			t.Fatalf("check after free failure %d: %v", i, err)
+		}
+	}
+
+	lockedUntil, err := guard.RecordFailure(ctx, "alice", "10.0.0.2")
+	if err != nil || lockedUntil == nil {
+		t.Fatalf("third failure did not lock: %v, %v", lockedUntil, err)
+	}
+	err = guard.Check(ctx, " ALICE ", "")
+	if !errors.Is(err, repositories.ErrAccountLocked) {
+		t.Fatalf("check while locked = %v, want ErrAccountLocked", err)
+	}
+	if err = guard.Check(ctx, "bob", "10.0.0.1"); err != nil {
+		t.Errorf("lock leaked to another user: %v", err)
+	}
+
+	if err = guard.RecordSuccess(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err = guard.Check(ctx, "alice", "10.0.0.1"); err != nil {
+		t.Errorf("check after success = %v, want nil", err)
+	}
+}
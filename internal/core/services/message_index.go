@@ -0,0 +1,373 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	snippetRadius  = 8 // tokens shown on each side of the first match
+	highlightStart = "**"
+	highlightEnd   = "**"
+)
+
+// MessageIndex is an in-memory inverted index over message content. It is
+// kept up to date by MessageService on send, edit and delete, and filled
+// from the repository with MessageService.RebuildIndex at startup.
+type MessageIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[domain.ID][]int // term -> messageID -> token positions
+	messages map[domain.ID]indexedMessage
+}
+
+type indexedMessage struct {
+	message domain.Message
+	tokens  []token
+}
+
+type token struct {
+	term       string
+	start, end int // byte offsets in the original content
+}
+
+func NewMessageIndex() *MessageIndex {
+	return &MessageIndex{
+		postings: make(map[string]map[domain.ID][]int),
+		messages: make(map[domain.ID]indexedMessage),
+	}
+}
+
+func (idx *MessageIndex) Add(message domain.Message) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(message.ID)
+
+	tokens := tokenize(message.Content)
+	for pos, t := range tokens {
+		docs, ok := idx.postings[t.term]
+		if !ok {
+			docs = make(map[domain.ID][]int)
+			idx.postings[t.term] = docs
+		}
+		docs[message.ID] = append(docs[message.ID], pos)
+	}
+	idx.messages[message.ID] = indexedMessage{message: message, tokens: tokens}
+}
+
+func (idx *MessageIndex) Remove(messageID domain.ID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(messageID)
+}
+
+func (idx *MessageIndex) RemoveChat(chatID domain.ID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for messageID, doc := range idx.messages {
+		if doc.message.ChatID == chatID {
+			idx.remove(messageID)
+		}
+	}
+}
+
+func (idx *MessageIndex) RemoveSender(userID domain.ID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for messageID, doc := range idx.messages {
+		if doc.message.SenderID == userID {
+			idx.remove(messageID)
+		}
+	}
+}
+
+func (idx *MessageIndex) remove(messageID domain.ID) {
+	doc, ok := idx.messages[messageID]
+	if !ok {
+		return
+	}
+	for _, t := range doc.tokens {
+		docs := idx.postings[t.term]
+		delete(docs, messageID)
+		if len(docs) == 0 {
+			delete(idx.postings, t.term)
+		}
+	}
+	delete(idx.messages, messageID)
+}
+
+// SearchScope maps each chat the search may look into to the creation time
+// of the oldest message visible there, or nil for the whole history.
+type SearchScope map[domain.ID]*time.Time
+
+// Search returns messages matching every term and phrase of the query,
+// restricted to the scope, ordered by relevance and then by recency.
+func (idx *MessageIndex) Search(query domain.SearchQuery, scope SearchScope) []domain.SearchResult {
+	phrases := parseQuery(query.Text)
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Messages outside the scope are dropped before anything else, and the
+	// term statistics only count messages in scope, so that neither the
+	// results nor their scores reveal anything about other chats.
+	keep := func(message domain.Message) bool {
+		return inScope(message, scope) && matchesFilters(message, query)
+	}
+	var candidates map[domain.ID][]int // messageID -> matched token positions
+	for _, phrase := range phrases {
+		matches := idx.matchPhrase(phrase, keep)
+		if candidates == nil {
+			candidates = matches
+			continue
+		}
+		for messageID, positions := range candidates {
+			more, ok := matches[messageID]
+			if !ok {
+				delete(candidates, messageID)
+				continue
+			}
+			candidates[messageID] = append(positions, more...)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	stats := idx.scopeStats(phrases, scope)
+	var results []domain.SearchResult
+	for messageID, positions := range candidates {
+		doc := idx.messages[messageID]
+		results = append(results, domain.SearchResult{
+			Message: doc.message,
+			Score:   stats.score(doc, phrases),
+			Snippet: snippet(doc, positions),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		a, b := results[i].Message.CreatedTime, results[j].Message.CreatedTime
+		if a != nil && b != nil && !a.Equal(*b) {
+			return a.After(*b)
+		}
+		return results[i].Message.ID < results[j].Message.ID
+	})
+
+	if query.Offset > 0 {
+		if query.Offset >= len(results) {
+			return nil
+		}
+		results = results[query.Offset:]
+	}
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results
+}
+
+// matchPhrase returns the messages accepted by keep that contain the phrase
+// terms in order, together with the positions of every matched token.
+func (idx *MessageIndex) matchPhrase(phrase []string, keep func(domain.Message) bool) map[domain.ID][]int {
+	matches := make(map[domain.ID][]int)
+	for messageID, starts := range idx.postings[phrase[0]] {
+		if !keep(idx.messages[messageID].message) {
+			continue
+		}
+		for _, start := range starts {
+			if !idx.phraseAt(messageID, phrase, start) {
+				continue
+			}
+			for i := range phrase {
+				matches[messageID] = append(matches[messageID], start+i)
+			}
+		}
+	}
+	return matches
+}
+
+func (idx *MessageIndex) phraseAt(messageID domain.ID, phrase []string, start int) bool {
+	tokens := idx.messages[messageID].tokens
+	if start+len(phrase) > len(tokens) {
+		return false
+	}
+	for i, term := range phrase {
+		if tokens[start+i].term != term {
+			return false
+		}
+	}
+	return true
+}
+
+// termStats holds the document frequencies used for scoring, counted over
+// the messages of a search scope only.
+type termStats struct {
+	total     int            // messages in scope
+	documents map[string]int // term -> messages in scope containing it
+}
+
+func (idx *MessageIndex) scopeStats(phrases [][]string, scope SearchScope) termStats {
+	stats := termStats{documents: make(map[string]int)}
+	for _, doc := range idx.messages {
+		if inScope(doc.message, scope) {
+			stats.total++
+		}
+	}
+	for _, phrase := range phrases {
+		for _, term := range phrase {
+			if _, ok := stats.documents[term]; ok {
+				continue
+			}
+			for messageID := range idx.postings[term] {
+				if inScope(idx.messages[messageID].message, scope) {
+					stats.documents[term]++
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// score is a plain tf-idf sum with a bonus for multi-term phrases. Every
+// term of the phrases must occur in doc.
+func (stats termStats) score(doc indexedMessage, phrases [][]string) float64 {
+	counts := make(map[string]int)
+	for _, t := range doc.tokens {
+		counts[t.term]++
+	}
+	var score float64
+	for _, phrase := range phrases {
+		var phraseScore float64
+		for _, term := range phrase {
+			tf := float64(counts[term]) / float64(len(doc.tokens))
+			idf := math.Log(1 + float64(stats.total)/float64(stats.documents[term]))
+			phraseScore += tf * idf
+		}
+		score += phraseScore * (1 + 0.5*float64(len(phrase)-1))
+	}
+	return score
+}
+
+func inScope(message domain.Message, scope SearchScope) bool {
+	cutoff, ok := scope[message.ChatID]
+	if !ok {
+		return false
+	}
+	if cutoff == nil {
+		return true
+	}
+	return message.CreatedTime != nil && !message.CreatedTime.Before(*cutoff)
+}
+
+func matchesFilters(message domain.Message, query domain.SearchQuery) bool {
+	if query.ChatID != "" && message.ChatID != query.ChatID {
+		return false
+	}
+	if query.SenderID != "" && message.SenderID != query.SenderID {
+		return false
+	}
+	for _, excluded := range query.ExcludeSenders {
+		if message.SenderID == excluded {
+			return false
+		}
+	}
+	if query.From != nil || query.To != nil {
+		if message.CreatedTime == nil {
+			return false
+		}
+		if query.From != nil && message.CreatedTime.Before(*query.From) {
+			return false
+		}
+		if query.To != nil && message.CreatedTime.After(*query.To) {
+			return false
+		}
+	}
+	return true
+}
+
+func snippet(doc indexedMessage, positions []int) string {
+	if len(doc.tokens) == 0 {
+		return ""
+	}
+	highlighted := make(map[int]bool, len(positions))
+	first := len(doc.tokens)
+	for _, pos := range positions {
+		highlighted[pos] = true
+		first = min(first, pos)
+	}
+	from := max(first-snippetRadius, 0)
+	to := min(first+snippetRadius, len(doc.tokens)-1)
+
+	content := doc.message.Content
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("...")
+	}
+	cursor := doc.tokens[from].start
+	for i := from; i <= to; i++ {
+		t := doc.tokens[i]
+		b.WriteString(content[cursor:t.start])
+		if highlighted[i] {
+			b.WriteString(highlightStart + content[t.start:t.end] + highlightEnd)
+		} else {
+			b.WriteString(content[t.start:t.end])
+		}
+		cursor = t.end
+	}
+	if to < len(doc.tokens)-1 {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// parseQuery splits the query into phrases; unquoted words are one-term phrases.
+func parseQuery(text string) [][]string {
+	var phrases [][]string
+	for i, part := range strings.Split(text, `"`) {
+		terms := tokenize(part)
+		if len(terms) == 0 {
+			continue
+		}
+		if i%2 == 1 {
+			phrase := make([]string, len(terms))
+			for j, t := range terms {
+				phrase[j] = t.term
+			}
+			phrases = append(phrases, phrase)
+			continue
+		}
+		for _, t := range terms {
+			phrases = append(phrases, []string{t.term})
+		}
+	}
+	return phrases
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
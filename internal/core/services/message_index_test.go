@@ -0,0 +1,187 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T) (*MessageIndex, time.Time) {
+	t.Helper()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		tm := base.Add(time.Duration(minutes) * time.Minute)
+		return &tm
+	}
+	idx := NewMessageIndex()
+	for _, m := range []domain.Message{
+		{ID: "m1", ChatID: "c1", SenderID: "alice", Content: "Deploy the release notes today", CreatedTime: at(0)},
+		{ID: "m2", ChatID: "c1", SenderID: "bob", Content: "The notes for the release are ready", CreatedTime: at(1)},
+		{ID: "m3", ChatID: "c2", SenderID: "alice", Content: "release release release", CreatedTime: at(2)},
+		{ID: "m4", ChatID: "c2", SenderID: "carol", Content: "lunch?", CreatedTime: at(3)},
+		{ID: "m5", ChatID: "c3", SenderID: "bob", Content: "secret release plans", CreatedTime: at(4)},
+	} {
+		idx.Add(m)
+	}
+	return idx, base
+}
+
+func resultIDs(results []domain.SearchResult) []domain.ID {
+	var ids []domain.ID
+	for _, r := range results {
+		ids = append(ids, r.Message.ID)
+	}
+	return ids
+}
+
+func TestMessageIndexSearch(t *testing.T) {
+	idx, base := newTestIndex(t)
+	after := base.Add(90 * time.Second)
+	scope := SearchScope{"c1": nil, "c2": nil}
+
+	tests := []struct {
+		name  string
+		query domain.SearchQuery
+		scope SearchScope
+		want  []domain.ID
+	}{
+		{
+			name:  "ranks by term frequency",
+			query: domain.SearchQuery{Text: "release"},
+			scope: scope,
+			want:  []domain.ID{"m3", "m1", "m2"},
+		},
+		{
+			name:  "all terms must match, in any case",
+			query: domain.SearchQuery{Text: "NOTES deploy"},
+			scope: scope,
+			want:  []domain.ID{"m1"},
+		},
+		{
+			name:  "phrase must match in order",
+			query: domain.SearchQuery{Text: `"release notes"`},
+			scope: scope,
+			want:  []domain.ID{"m1"},
+		},
+		{
+			name:  "chats outside the scope are skipped",
+			query: domain.SearchQuery{Text: "secret"},
+			scope: scope,
+			want:  nil,
+		},
+		{
+			name:  "scope cutoff hides older messages",
+			query: domain.SearchQuery{Text: "release"},
+			scope: SearchScope{"c1": &after, "c2": nil},
+			want:  []domain.ID{"m3"},
+		},
+		{
+			name:  "chat filter",
+			query: domain.SearchQuery{Text: "release", ChatID: "c1"},
+			scope: scope,
+			want:  []domain.ID{"m1", "m2"},
+		},
+		{
+			name:  "sender filter",
+			query: domain.SearchQuery{Text: "release", SenderID: "bob"},
+			scope: scope,
+			want:  []domain.ID{"m2"},
+		},
+		{
+			name:  "excluded senders",
+			query: domain.SearchQuery{Text: "release", ExcludeSenders: []domain.ID{"alice"}},
+			scope: scope,
+			want:  []domain.ID{"m2"},
+		},
+		{
+			name:  "time range",
+			query: domain.SearchQuery{Text: "release", From: &after},
+			scope: scope,
+			want:  []domain.ID{"m3"},
+		},
+		{
+			name:  "limit and offset",
+			query: domain.SearchQuery{Text: "release", Offset: 1, Limit: 1},
+			scope: scope,
+			want:  []domain.ID{"m1"},
+		},
+		{
+			name:  "offset past the end",
+			query: domain.SearchQuery{Text: "release", Offset: 10},
+			scope: scope,
+			want:  nil,
+		},
+		{
+			name:  "no terms",
+			query: domain.SearchQuery{Text: `"" ?!`},
+			scope: scope,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resultIDs(idx.Search(tt.query, tt.scope))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query.Text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageIndexSnippet(t *testing.T) {
+	idx, _ := newTestIndex(t)
+	results := idx.Search(domain.SearchQuery{Text: "deploy"}, SearchScope{"c1": nil})
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if want := "**Deploy** the release notes today"; results[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+}
+
+func TestMessageIndexUpdates(t *testing.T) {
+	idx, _ := newTestIndex(t)
+	scope := SearchScope{"c1": nil, "c2": nil, "c3": nil}
+	search := func(text string) []domain.ID {
+		return resultIDs(idx.Search(domain.SearchQuery{Text: text}, scope))
+	}
+
+	idx.Add(domain.Message{ID: "m4", ChatID: "c2", SenderID: "carol", Content: "dinner?"})
+	if got := search("lunch"); got != nil {
+		t.Errorf("edited message still found by old content: %v", got)
+	}
+	if got := search("dinner"); !reflect.DeepEqual(got, []domain.ID{"m4"}) {
+		t.Errorf("edited message not found by new content: %v", got)
+	}
+
+	idx.Remove("m1")
+	if got := search("deploy"); got != nil {
+		t.Errorf("removed message still found: %v", got)
+	}
+
+	idx.RemoveChat("c2")
+	if got := search("dinner"); got != nil {
+		t.Errorf("message of removed chat still found: %v", got)
+	}
+
+	idx.RemoveSender("bob")
+	if got := search("release"); got != nil {
+		t.Errorf("messages of removed sender still found: %v", got)
+	}
+}
+
+func TestMessageIndexScoresIgnoreOtherChats(t *testing.T) {
+	idx, _ := newTestIndex(t)
+	scope := SearchScope{"c1": nil}
+	before := idx.Search(domain.SearchQuery{Text: "release notes"}, scope)
+
+	for i, content := range []string{"release", "release notes", "notes notes", "unrelated"} {
+		idx.Add(domain.Message{ID: domain.ID(fmt.Sprintf("x%d", i)), ChatID: "c9", Content: content})
+	}
+	after := idx.Search(domain.SearchQuery{Text: "release notes"}, scope)
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("messages outside the scope changed the results:\n got %+v\nwant %+v", after, before)
+	}
+}
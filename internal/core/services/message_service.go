@@ -3,40 +3,192 @@ package services
 import (
 	"chat-app/internal/core/domain"
 	"chat-app/internal/core/repositories"
-	"fmt"
+	"context"
+	"errors"
+	"strings"
 )
 
 type MessageService struct {
 	Message repositories.MessageRepository
 	Chat    repositories.ChatRepository
+	Index   *MessageIndex
 }
 
-func NewMessageService(message repositories.MessageRepository) *MessageService {
+func NewMessageService(message repositories.MessageRepository, index *MessageIndex) *MessageService {
 	return &MessageService{
 		Message: message,
+		Index:   index,
 	}
 }
 
-func (ms *MessageService) SendMessage(chatID, userID domain.ID, message string) error {
-	if message == "" {
-		return fmt.Errorf("message cannot be empty")
+// ValidateMessage checks the content of a text message.
+func ValidateMessage(message string) error {
+	var v domain.Validator
+	v.MessageContent("message", message, true)
+	return v.Err()
+}
+
+// ValidateMedia checks a media message.
+func ValidateMedia(caption string, attachments []domain.Attachment) error {
+	var v domain.Validator
+	v.MessageContent("caption", caption, false)
+	v.Check(len(attachments) > 0, "attachments", "cannot be empty")
+	return v.Err()
+}
+
+func (ms *MessageService) SendMessage(ctx context.Context, chatID, userID domain.ID, message string) error {
+	if err := ValidateMessage(message); err != nil {
+		return err
+	}
+
+	sent, err := ms.Message.SendMessage(ctx, chatID, userID, message)
+	if err != nil {
+		return domain.Wrap(err, "failed to send message")
+	}
+	ms.Index.Add(sent)
+	return nil
+}
+
+func (ms *MessageService) SendMedia(ctx context.Context, chatID, userID domain.ID, caption string, attachments []domain.Attachment) error {
+	if err := ValidateMedia(caption, attachments); err != nil {
+		return err
+	}
+
+	sent, err := ms.Message.SendMedia(ctx, chatID, userID, caption, attachments)
+	if err != nil {
+		return domain.Wrap(err, "failed to send media")
+	}
+	ms.Index.Add(sent)
+	return nil
+}
+
+func (ms *MessageService) FindMessage(ctx context.Context, messageID domain.ID) (domain.Message, error) {
+	if messageID == "" {
+		return domain.Message{}, domain.InvalidArgument("messageID cannot be empty")
+	}
+	message, err := ms.Message.FindMessage(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMessageNotFound) {
+			return domain.Message{}, domain.Wrap(repositories.ErrMessageNotFound, "message doesn't exist")
+		}
+		return domain.Message{}, domain.Wrap(err, "failed to find message")
+	}
+	return message, nil
+}
+
+func (ms *MessageService) ListUserMessages(ctx context.Context, userID domain.ID) ([]domain.Message, error) {
+	if userID == "" {
+		return nil, domain.InvalidArgument("userID cannot be empty")
+	}
+	messages, err := ms.Message.ListUserMessages(ctx, userID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list user messages")
+	}
+	return messages, nil
+}
+
+func (ms *MessageService) EditMessage(ctx context.Context, chatID, userID, messageID domain.ID, message string) error {
+	var v domain.Validator
+	v.Required("chat_id", string(chatID))
+	v.Required("message_id", string(messageID))
+	v.MessageContent("message", message, true)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	edited, err := ms.Message.EditMessage(ctx, chatID, userID, messageID, message)
+	if err != nil {
+		return domain.Wrap(err, "failed to edit message")
 	}
+	ms.Index.Add(edited)
+	return nil
+}
 
-	if err := ms.Message.SendMessage(chatID, userID, message); err != nil {
-		return fmt.Errorf("failed to send message: %v", err)
+func (ms *MessageService) DeleteMessage(ctx context.Context, chatID, userID, messageID domain.ID) error {
+	if chatID == "" {
+		return domain.InvalidArgument("chatID cannot be empty")
+	}
+	if messageID == "" {
+		return domain.InvalidArgument("messageID cannot be empty")
+	}
+	if err := ms.Message.DeleteMessage(ctx, chatID, userID, messageID); err != nil {
+		return domain.Wrap(err, "failed to delete message")
 	}
+	ms.Index.Remove(messageID)
 	return nil
 }
 
-func (ms *MessageService) DeleteMessage(chatID, userID, messageID domain.ID) error {
+// DeleteChatMessages permanently removes all messages of a chat.
+func (ms *MessageService) DeleteChatMessages(ctx context.Context, chatID domain.ID) error {
 	if chatID == "" {
-		return fmt.Errorf("chatID cannot be empty")
+		return domain.InvalidArgument("chatID cannot be empty")
+	}
+	if err := ms.Message.DeleteChatMessages(ctx, chatID); err != nil {
+		return domain.Wrap(err, "failed to delete chat messages")
+	}
+	ms.Index.RemoveChat(chatID)
+	return nil
+}
+
+func (ms *MessageService) DeleteUserMessages(ctx context.Context, userID domain.ID) error {
+	if userID == "" {
+		return domain.InvalidArgument("userID cannot be empty")
+	}
+	if err := ms.Message.DeleteUserMessages(ctx, userID); err != nil {
+		return domain.Wrap(err, "failed to delete user messages")
 	}
+	ms.Index.RemoveSender(userID)
+	return nil
+}
+
+func (ms *MessageService) AddView(ctx context.Context, messageID, userID domain.ID) error {
 	if messageID == "" {
-		return fmt.Errorf("messageID cannot be empty")
+		return domain.InvalidArgument("messageID cannot be empty")
+	}
+	if userID == "" {
+		return domain.InvalidArgument("userID cannot be empty")
 	}
-	if err := ms.Message.DeleteMessage(chatID, userID, messageID); err != nil {
-		return fmt.Errorf("failed to delete message: %v", err)
+	if err := ms.Message.AddView(ctx, messageID, userID); err != nil {
+		return domain.Wrap(err, "failed to add message view")
 	}
 	return nil
 }
+
+func (ms *MessageService) SearchMessages(ctx context.Context, query domain.SearchQuery, scope SearchScope) ([]domain.SearchResult, error) {
+	if strings.TrimSpace(query.Text) == "" {
+		return nil, domain.InvalidArgument("search text cannot be empty")
+	}
+	if query.From != nil && query.To != nil && query.From.After(*query.To) {
+		return nil, domain.InvalidArgument("search date range is invalid")
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return nil, domain.InvalidArgument("search limit and offset cannot be negative")
+	}
+	return ms.Index.Search(query, scope), nil
+}
+
+const indexRebuildPageSize = 500
+
+// RebuildIndex adds every stored message to the search index, which only
+// lives in memory. Run it at startup, before serving searches. Messages sent
+// meanwhile are indexed as usual and not lost.
+func (ms *MessageService) RebuildIndex(ctx context.Context) (indexed int, err error) {
+	var afterID domain.ID
+	for {
+		if err = ctx.Err(); err != nil {
+			return indexed, err
+		}
+		messages, err := ms.Message.ListMessages(ctx, afterID, indexRebuildPageSize)
+		if err != nil {
+			return indexed, domain.Wrap(err, "failed to list messages")
+		}
+		for _, message := range messages {
+			ms.Index.Add(message)
+		}
+		indexed += len(messages)
+		if len(messages) < indexRebuildPageSize {
+			return indexed, nil
+		}
+		afterID = messages[len(messages)-1].ID
+	}
+}
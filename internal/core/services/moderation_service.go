@@ -0,0 +1,143 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ModerationService struct {
+	Restriction repositories.RestrictionRepository
+}
+
+func NewModerationService(restriction repositories.RestrictionRepository) *ModerationService {
+	return &ModerationService{Restriction: restriction}
+}
+
+// Restrict bans or mutes the user. A zero duration is permanent.
+func (ms *ModerationService) Restrict(ctx context.Context, restriction domain.Restriction, duration time.Duration) (domain.Restriction, error) {
+	if restriction.ChatID == "" {
+		return domain.Restriction{}, domain.InvalidArgument("chatID cannot be empty")
+	}
+	if restriction.UserID == "" {
+		return domain.Restriction{}, domain.InvalidArgument("userID cannot be empty")
+	}
+	if restriction.Type != domain.Banned && restriction.Type != domain.Muted {
+		return domain.Restriction{}, domain.InvalidArgument("invalid restriction type: %v", restriction.Type)
+	}
+	if duration < 0 {
+		return domain.Restriction{}, domain.InvalidArgument("restriction duration cannot be negative")
+	}
+
+	now := time.Now()
+	restriction.CreatedTime = &now
+	restriction.ExpiresTime = nil
+	if duration > 0 {
+		expires := now.Add(duration)
+		restriction.ExpiresTime = &expires
+	}
+
+	if err := ms.Restriction.AddRestriction(ctx, restriction); err != nil {
+		return domain.Restriction{}, domain.Wrap(err, "failed to add restriction")
+	}
+	return restriction, nil
+}
+
+func (ms *ModerationService) Lift(ctx context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) error {
+	if chatID == "" {
+		return domain.InvalidArgument("chatID cannot be empty")
+	}
+	if userID == "" {
+		return domain.InvalidArgument("userID cannot be empty")
+	}
+	err := ms.Restriction.RemoveRestriction(ctx, chatID, userID, restrictionType)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRestrictionNotFound) {
+			return domain.Wrap(repositories.ErrRestrictionNotFound, "user is not %v", restrictionType)
+		}
+		return domain.Wrap(err, "failed to lift restriction")
+	}
+	return nil
+}
+
+// ActiveRestriction reports whether the user currently has a restriction of
+// the given type. Expired restrictions are treated as lifted.
+func (ms *ModerationService) ActiveRestriction(ctx context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) (domain.Restriction, bool, error) {
+	restriction, err := ms.Restriction.FindRestriction(ctx, chatID, userID, restrictionType)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRestrictionNotFound) {
+			return domain.Restriction{}, false, nil
+		}
+		return domain.Restriction{}, false, domain.Wrap(err, "failed to find restriction")
+	}
+	if !restriction.IsActive(time.Now()) {
+		return domain.Restriction{}, false, nil
+	}
+	return restriction, true, nil
+}
+
+func (ms *ModerationService) ListActive(ctx context.Context, chatID domain.ID) ([]domain.Restriction, error) {
+	if chatID == "" {
+		return nil, domain.InvalidArgument("chatID cannot be empty")
+	}
+	restrictions, err := ms.Restriction.ListRestrictions(ctx, chatID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list restrictions")
+	}
+
+	now := time.Now()
+	var active []domain.Restriction
+	for _, restriction := range restrictions {
+		if restriction.IsActive(now) {
+			active = append(active, restriction)
+		}
+	}
+	return active, nil
+}
+
+// PurgeExpired deletes restrictions whose time has run out.
+func (ms *ModerationService) PurgeExpired(ctx context.Context) (int, error) {
+	deleted, err := ms.Restriction.DeleteExpiredRestrictions(ctx, time.Now())
+	if err != nil {
+		return 0, domain.Wrap(err, "failed to purge expired restrictions")
+	}
+	return deleted, nil
+}
+
+// CheckCanPost returns an error wrapping ErrUserMuted while the user is muted.
+func (ms *ModerationService) CheckCanPost(ctx context.Context, chatID, userID domain.ID) error {
+	mute, muted, err := ms.ActiveRestriction(ctx, chatID, userID, domain.Muted)
+	if err != nil {
+		return err
+	}
+	if !muted {
+		return nil
+	}
+	return domain.Wrap(repositories.ErrUserMuted, "you are muted in this chat%s", describeRestriction(mute))
+}
+
+// CheckCanJoin returns an error wrapping ErrUserBanned while the user is banned.
+func (ms *ModerationService) CheckCanJoin(ctx context.Context, chatID, userID domain.ID) error {
+	ban, banned, err := ms.ActiveRestriction(ctx, chatID, userID, domain.Banned)
+	if err != nil {
+		return err
+	}
+	if !banned {
+		return nil
+	}
+	return domain.Wrap(repositories.ErrUserBanned, "you are banned from this chat%s", describeRestriction(ban))
+}
+
+func describeRestriction(restriction domain.Restriction) string {
+	var description string
+	if restriction.ExpiresTime != nil {
+		description += fmt.Sprintf(" until %s", restriction.ExpiresTime.Format(time.RFC3339))
+	}
+	if restriction.Reason != "" {
+		description += fmt.Sprintf(": %s", restriction.Reason)
+	}
+	return description
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateLimits configures each operation per user tier. Tiers missing for an
+// operation fall back to TierStandard; operations missing entirely are not
+// limited.
+type RateLimits map[domain.Operation]map[domain.UserTier]domain.RateLimit
+
+var DefaultRateLimits = RateLimits{
+	domain.OpSendMessage: {
+		domain.TierStandard: {Burst: 30, RefillEvery: time.Second},
+		domain.TierPremium:  {Burst: 60, RefillEvery: 500 * time.Millisecond},
+	},
+	domain.OpCreateChat: {
+		domain.TierStandard: {Burst: 10, RefillEvery: 6 * time.Minute},
+		domain.TierPremium:  {Burst: 30, RefillEvery: 2 * time.Minute},
+	},
+	domain.OpCreateInvite: {
+		domain.TierStandard: {Burst: 20, RefillEvery: 3 * time.Minute},
+		domain.TierPremium:  {Burst: 50, RefillEvery: time.Minute},
+	},
+	domain.OpLogin: {
+		domain.TierStandard: {Burst: 20, RefillEvery: 6 * time.Second},
+	},
+}
+
+// RateLimitError is returned when an operation is attempted too often. It
+// matches repositories.ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Operation  domain.Operation
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v for %s: retry in %s", repositories.ErrRateLimited, e.Operation, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == repositories.ErrRateLimited
+}
+
+func (e *RateLimitError) ErrorCode() domain.ErrorCode {
+	return domain.CodeRateLimited
+}
+
+type RateLimiter struct {
+	Store  repositories.RateLimitStore
+	Limits RateLimits
+}
+
+func NewRateLimiter(store repositories.RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		Store:  store,
+		Limits: DefaultRateLimits,
+	}
+}
+
+// AllowUser takes one token from the user's bucket for the operation, or
+// returns a *RateLimitError.
+func (rl *RateLimiter) AllowUser(ctx context.Context, operation domain.Operation, userID domain.ID, tier domain.UserTier) error {
+	if userID == "" {
+		return domain.InvalidArgument("userID cannot be empty")
+	}
+	return rl.allow(ctx, operation, "id:"+string(userID), tier)
+}
+
+// AllowIP is AllowUser for callers that are not logged in yet, using the
+// standard tier. Callers without an ip are limited by username instead, and
+// rejected if they have neither.
+func (rl *RateLimiter) AllowIP(ctx context.Context, operation domain.Operation, ip, username string) error {
+	if ip != "" {
+		return rl.allow(ctx, operation, "ip:"+ip, domain.TierStandard)
+	}
+	if username = strings.ToLower(strings.TrimSpace(username)); username != "" {
+		return rl.allow(ctx, operation, "user:"+username, domain.TierStandard)
+	}
+	return domain.InvalidArgument("an ip or username is required")
+}
+
+func (rl *RateLimiter) allow(ctx context.Context, operation domain.Operation, key string, tier domain.UserTier) error {
+	limit, ok := rl.limit(operation, tier)
+	if !ok {
+		return nil
+	}
+	retryAfter, err := rl.Store.Take(ctx, string(operation)+":"+key, limit, time.Now())
+	if err != nil {
+		return domain.Wrap(err, "failed to check rate limit")
+	}
+	if retryAfter > 0 {
+		return &RateLimitError{Operation: operation, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (rl *RateLimiter) limit(operation domain.Operation, tier domain.UserTier) (domain.RateLimit, bool) {
+	tiers, ok := rl.Limits[operation]
+	if !ok {
+		return domain.RateLimit{}, false
+	}
+	if limit, ok := tiers[tier]; ok {
+		return limit, true
+	}
+	limit, ok := tiers[domain.TierStandard]
+	return limit, ok
+}
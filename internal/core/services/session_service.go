@@ -3,8 +3,8 @@ package services
 import (
 	"chat-app/internal/core/domain"
 	"chat-app/internal/core/repositories"
+	"context"
 	"errors"
-	"fmt"
 	"time"
 )
 
@@ -16,103 +16,129 @@ func NewSessionService(sessionRepo repositories.SessionRepository) *SessionServi
 	return &SessionService{SessionRepo: sessionRepo}
 }
 
-func (s *SessionService) CreateSession(session domain.Session, ttl time.Duration) error {
+func (s *SessionService) CreateSession(ctx context.Context, session domain.Session, ttl time.Duration) error {
 	if session.SessionID == "" {
-		return fmt.Errorf("sessionID cannot be empty")
+		return domain.InvalidArgument("sessionID cannot be empty")
 	}
 	if session.UserID == "" {
-		return fmt.Errorf("userID cannot be empty")
+		return domain.InvalidArgument("userID cannot be empty")
 	}
-	return s.SessionRepo.CreateSession(session, ttl)
+	return s.SessionRepo.CreateSession(ctx, session, ttl)
 }
 
-func (s *SessionService) GetSession(sessionId domain.ID) (domain.Session, error) {
+func (s *SessionService) GetSession(ctx context.Context, sessionId domain.ID) (domain.Session, error) {
 	if sessionId == "" {
-		return domain.Session{}, errors.New("sessionID cannot be empty")
+		return domain.Session{}, domain.InvalidArgument("sessionID cannot be empty")
 	}
-	session, err := s.SessionRepo.GetSession(sessionId)
+	session, err := s.SessionRepo.GetSession(ctx, sessionId)
 	if err != nil {
-		return domain.Session{}, fmt.Errorf("error getting session: %v", err)
+		return domain.Session{}, domain.Wrap(err, "error getting session")
 	}
 	return session, nil
 }
 
-func (s *SessionService) GetSessionByUserID(userID domain.ID) (domain.Session, error) {
+func (s *SessionService) GetSessionByUserID(ctx context.Context, userID domain.ID) (domain.Session, error) {
 	if userID == "" {
-		return domain.Session{}, errors.New("userID cannot be empty")
+		return domain.Session{}, domain.InvalidArgument("userID cannot be empty")
 	}
-	session, err := s.SessionRepo.GetSessionByUserID(userID)
+	session, err := s.SessionRepo.GetSessionByUserID(ctx, userID)
 	if err != nil {
-		return domain.Session{}, fmt.Errorf("error getting session: %v", err)
+		return domain.Session{}, domain.Wrap(err, "error getting session")
 	}
 	return session, nil
 }
 
-func (s *SessionService) AddChatToSession(sessionID domain.ID, chatID domain.ID, role string) error {
+func (s *SessionService) ListUserSessions(ctx context.Context, userID domain.ID) ([]domain.Session, error) {
+	if userID == "" {
+		return nil, domain.InvalidArgument("userID cannot be empty")
+	}
+	sessions, err := s.SessionRepo.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, domain.Wrap(err, "error listing sessions")
+	}
+	return sessions, nil
+}
+
+func (s *SessionService) AddChatToSession(ctx context.Context, sessionID domain.ID, chatID domain.ID, chatName string, role string) error {
 	if sessionID == "" {
-		return fmt.Errorf("sessionID cannot be empty")
+		return domain.InvalidArgument("sessionID cannot be empty")
 	}
 	if chatID == "" {
-		return fmt.Errorf("chatID cannot be empty")
+		return domain.InvalidArgument("chatID cannot be empty")
+	}
+	if chatName == "" {
+		return domain.InvalidArgument("chatName cannot be empty")
 	}
 	if role == "" {
-		return fmt.Errorf("role cannot be empty")
+		return domain.InvalidArgument("role cannot be empty")
 	}
-	return s.SessionRepo.AddChatToSession(sessionID, chatID, role)
+	return s.SessionRepo.AddChatToSession(ctx, sessionID, chatID, chatName, role)
 }
 
-func (s *SessionService) RemoveChatFromSession(sessionID domain.ID, chatID domain.ID) error {
+func (s *SessionService) RemoveChatFromSession(ctx context.Context, sessionID domain.ID, chatID domain.ID) error {
 	if sessionID == "" {
-		return fmt.Errorf("sessionID cannot be empty")
+		return domain.InvalidArgument("sessionID cannot be empty")
 	}
 	if chatID == "" {
-		return fmt.Errorf("chatID cannot be empty")
+		return domain.InvalidArgument("chatID cannot be empty")
 	}
-	if err := s.SessionRepo.RemoveChatFromSession(sessionID, chatID); err != nil {
+	if err := s.SessionRepo.RemoveChatFromSession(ctx, sessionID, chatID); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *SessionService) UpdateChatRole(sessionID domain.ID, chatID domain.ID, role string) error {
+func (s *SessionService) UpdateChatRole(ctx context.Context, sessionID domain.ID, chatID domain.ID, role string) error {
 	if sessionID == "" {
-		return fmt.Errorf("sessionID cannot be empty")
+		return domain.InvalidArgument("sessionID cannot be empty")
 	}
 	if chatID == "" {
-		return fmt.Errorf("chatID cannot be empty")
+		return domain.InvalidArgument("chatID cannot be empty")
 	}
 	if role == "" {
-		return fmt.Errorf("role cannot be empty")
+		return domain.InvalidArgument("role cannot be empty")
 	}
-	if err := s.SessionRepo.UpdateChatRole(sessionID, chatID, role); err != nil {
+	if err := s.SessionRepo.UpdateChatRole(ctx, sessionID, chatID, role); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *SessionService) IsUserInChat(sessionID domain.ID, chatID domain.ID) (string, error) {
+func (s *SessionService) IsUserInChat(ctx context.Context, sessionID domain.ID, chatID domain.ID) (string, error) {
 	if sessionID == "" {
-		return "", fmt.Errorf("sessionID cannot be empty")
+		return "", domain.InvalidArgument("sessionID cannot be empty")
 	}
 	if chatID == "" {
-		return "", fmt.Errorf("chatID cannot be empty")
+		return "", domain.InvalidArgument("chatID cannot be empty")
 	}
-	role, err := s.SessionRepo.IsUserInChat(sessionID, chatID)
+	role, err := s.SessionRepo.IsUserInChat(ctx, sessionID, chatID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrUserNotFound) {
-			return "", fmt.Errorf("user not found in chat:%v", err)
+			return "", domain.Wrap(err, "user not found in chat")
 		}
-		return "", fmt.Errorf("error while checking if user is in chat:%v", err)
+		return "", domain.Wrap(err, "error while checking if user is in chat")
 	}
 	return role, nil
 }
 
-func (s *SessionService) DeleteSession(sessionID domain.ID) error {
+func (s *SessionService) DeleteSession(ctx context.Context, sessionID domain.ID) error {
 	if sessionID == "" {
-		return fmt.Errorf("sessionID cannot be empty")
+		return domain.InvalidArgument("sessionID cannot be empty")
 	}
-	if err := s.SessionRepo.DeleteSession(sessionID); err != nil {
+	if err := s.SessionRepo.DeleteSession(ctx, sessionID); err != nil {
 		return err
 	}
 	return nil
 }
+
+// DeleteUserSessions revokes every session of the user, e.g. when their
+// account is deleted.
+func (s *SessionService) DeleteUserSessions(ctx context.Context, userID domain.ID) error {
+	if userID == "" {
+		return domain.InvalidArgument("userID cannot be empty")
+	}
+	if err := s.SessionRepo.DeleteUserSessions(ctx, userID); err != nil {
+		return domain.Wrap(err, "failed to delete the user's sessions")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+// SlowModeError is returned while a member has to wait before posting
+// again. It matches repositories.ErrSlowMode with errors.Is.
+type SlowModeError struct {
+	Remaining time.Duration
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("%v: wait %s before sending another message", repositories.ErrSlowMode, e.Remaining.Round(time.Second))
+}
+
+func (e *SlowModeError) Is(target error) bool {
+	return target == repositories.ErrSlowMode
+}
+
+func (e *SlowModeError) ErrorCode() domain.ErrorCode {
+	return domain.CodeRateLimited
+}
+
+// ReservePost returns a *SlowModeError if the user posted in the chat more
+// recently than its slow mode interval allows, and otherwise records now as
+// their last post. The time is kept apart from the messages, so deleting a
+// message does not reset it, and the repository checks and records it
+// atomically, so concurrent sends cannot both pass. Call it once every
+// other check has passed but before taking a rate limit token, so that a
+// post slow mode rejects does not use one up. Admins and the owner are
+// exempt.
+func (ms *ModerationService) ReservePost(ctx context.Context, chat domain.Chat, userID domain.ID) error {
+	if chat.Settings.SlowMode <= 0 {
+		return nil
+	}
+	if role := chat.RoleOf(userID); role == domain.Owner || role == domain.Admin {
+		return nil
+	}
+
+	remaining, err := ms.Restriction.ReservePost(ctx, chat.ID, userID, time.Now(), chat.Settings.SlowMode)
+	if err != nil {
+		return domain.Wrap(err, "failed to check slow mode")
+	}
+	if remaining > 0 {
+		return &SlowModeError{Remaining: remaining}
+	}
+	return nil
+}
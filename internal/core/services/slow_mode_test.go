@@ -0,0 +1,90 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"chat-app/internal/infrastructure/memory"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReservePost(t *testing.T) {
+	ms := NewModerationService(memory.NewRestrictionRepository())
+	ctx := context.Background()
+	chat := domain.Chat{
+		ID:       "chat",
+		ChatType: domain.Group,
+		Owner:    "owner",
+		Admins:   []domain.ID{"admin"},
+		Members:  []domain.ID{"owner", "admin", "member", "other"},
+		Settings: domain.ChatSettings{SlowMode: time.Minute},
+	}
+
+	if err := ms.ReservePost(ctx, chat, "member"); err != nil {
+		t.Fatalf("first post: %v", err)
+	}
+	err := ms.ReservePost(ctx, chat, "member")
+	var slow *SlowModeError
+	if !errors.As(err, &slow) {
+		t.Fatalf("second post: error = %v, want *SlowModeError", err)
+	}
+	if slow.Remaining <= 0 || slow.Remaining > time.Minute {
+		t.Errorf("Remaining = %v, want within (0, 1m]", slow.Remaining)
+	}
+	if !errors.Is(err, repositories.ErrSlowMode) || domain.CodeOf(err) != domain.CodeRateLimited {
+		t.Errorf("error = %v (code %q), want ErrSlowMode with code %q", err, domain.CodeOf(err), domain.CodeRateLimited)
+	}
+
+	// Each member has their own interval; admins and the owner have none.
+	for _, userID := range []domain.ID{"other", "owner", "owner", "admin", "admin"} {
+		if err := ms.ReservePost(ctx, chat, userID); err != nil {
+			t.Errorf("post by %s: %v", userID, err)
+		}
+	}
+
+	chat.Settings.SlowMode = 0
+	if err := ms.ReservePost(ctx, chat, "member"); err != nil {
+		t.Errorf("post with slow mode off: %v", err)
+	}
+}
+
+func TestReservePostConcurrent(t *testing.T) {
+	ms := NewModerationService(memory.NewRestrictionRepository())
+	ctx := context.Background()
+	chat := domain.Chat{
+		ID:       "chat",
+		ChatType: domain.Group,
+		Owner:    "owner",
+		Members:  []domain.ID{"owner", "member"},
+		Settings: domain.ChatSettings{SlowMode: time.Minute},
+	}
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- ms.ReservePost(ctx, chat, "member")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var posted int
+	for err := range errs {
+		switch {
+		case err == nil:
+			posted++
+		case !errors.Is(err, repositories.ErrSlowMode):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if posted != 1 {
+		t.Errorf("posted %d times, want 1", posted)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TokenPurpose string
+
+const (
+	TokenVerifyEmail   TokenPurpose = "verify_email"
+	TokenResetPassword TokenPurpose = "reset_password"
+)
+
+// TokenClaims is what a token vouches for. Subject binds the token to a
+// value that must still hold when it is used, e.g. the email address being
+// verified.
+type TokenClaims struct {
+	Purpose     TokenPurpose
+	UserID      domain.ID
+	Subject     string
+	ExpiresTime time.Time
+	nonce       string
+}
+
+// TokenService issues HMAC-signed, single-use tokens for links sent by email.
+type TokenService struct {
+	Tokens repositories.TokenRepository
+	Secret []byte
+}
+
+func NewTokenService(tokens repositories.TokenRepository, secret []byte) *TokenService {
+	return &TokenService{Tokens: tokens, Secret: secret}
+}
+
+func (ts *TokenService) Issue(ctx context.Context, purpose TokenPurpose, userID domain.ID, subject string, ttl time.Duration) (string, error) {
+	if len(ts.Secret) == 0 {
+		return "", domain.NewError(domain.CodeInternal, "token secret is not configured")
+	}
+	if userID == "" {
+		return "", domain.InvalidArgument("userID cannot be empty")
+	}
+	if ttl <= 0 {
+		return "", domain.InvalidArgument("token ttl must be positive")
+	}
+	nonce, err := newRandomToken()
+	if err != nil {
+		return "", domain.Wrap(err, "failed to generate token")
+	}
+
+	fields := []string{
+		string(purpose),
+		string(userID),
+		subject,
+		strconv.FormatInt(time.Now().Add(ttl).Unix(), 10),
+		nonce,
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strings.Join(fields, "\n")))
+	return payload + "." + ts.sign(payload), nil
+}
+
+// Consume checks the token's signature, purpose and expiry and marks it as
+// used, so that it cannot be used again.
+func (ts *TokenService) Consume(ctx context.Context, purpose TokenPurpose, token string) (TokenClaims, error) {
+	claims, err := ts.parse(token)
+	if err != nil {
+		return TokenClaims{}, err
+	}
+	if claims.Purpose != purpose || !time.Now().Before(claims.ExpiresTime) {
+		return TokenClaims{}, repositories.ErrInvalidToken
+	}
+
+	if err = ts.Tokens.MarkTokenUsed(ctx, claims.nonce, claims.ExpiresTime); err != nil {
+		if errors.Is(err, repositories.ErrTokenUsed) {
+			return TokenClaims{}, repositories.ErrTokenUsed
+		}
+		return TokenClaims{}, domain.Wrap(err, "failed to use token")
+	}
+	return claims, nil
+}
+
+func (ts *TokenService) parse(token string) (TokenClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(ts.sign(payload))) {
+		return TokenClaims{}, repositories.ErrInvalidToken
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return TokenClaims{}, repositories.ErrInvalidToken
+	}
+	fields := strings.Split(string(decoded), "\n")
+	if len(fields) != 5 {
+		return TokenClaims{}, repositories.ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return TokenClaims{}, repositories.ErrInvalidToken
+	}
+	return TokenClaims{
+		Purpose:     TokenPurpose(fields[0]),
+		UserID:      domain.ID(fields[1]),
+		Subject:     fields[2],
+		ExpiresTime: time.Unix(expires, 0),
+		nonce:       fields[4],
+	}, nil
+}
+
+func (ts *TokenService) sign(payload string) string {
+	mac := hmac.New(sha256.New, ts.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"chat-app/internal/core/repositories"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTokenRepository struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+func (r *fakeTokenRepository) MarkTokenUsed(_ context.Context, tokenID string, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used[tokenID] {
+		return repositories.ErrTokenUsed
+	}
+	r.used[tokenID] = true
+	return nil
+}
+
+func newTestTokenService() *TokenService {
+	return NewTokenService(&fakeTokenRepository{used: make(map[string]bool)}, []byte("test secret"))
+}
+
+func TestTokenServiceIssueAndConsume(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestTokenService()
+
+	token, err := ts.Issue(ctx, TokenVerifyEmail, "user-1", "alice@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ts.Consume(ctx, TokenVerifyEmail, token)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if claims.Purpose != TokenVerifyEmail || claims.UserID != "user-1" || claims.Subject != "alice@example.com" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if _, err = ts.Consume(ctx, TokenVerifyEmail, token); !errors.Is(err, repositories.ErrTokenUsed) {
+		t.Errorf("replayed token: err = %v, want ErrTokenUsed", err)
+	}
+}
+
+func TestTokenServiceRejects(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestTokenService()
+
+	valid, err := ts.Issue(ctx, TokenResetPassword, "user-1", "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte("reset_password\nuser-2\n\n9999999999\nnonce"))
+	expiredPayload := base64.RawURLEncoding.EncodeToString([]byte("reset_password\nuser-1\n\n1\nnonce"))
+	other := NewTokenService(&fakeTokenRepository{used: make(map[string]bool)}, []byte("other secret"))
+	otherToken, err := other.Issue(ctx, TokenResetPassword, "user-1", "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		purpose TokenPurpose
+		token   string
+	}{
+		{"wrong purpose", TokenVerifyEmail, valid},
+		{"empty", TokenResetPassword, ""},
+		{"no signature", TokenResetPassword, payload},
+		{"tampered payload", TokenResetPassword, forged + "." + signature},
+		{"tampered signature", TokenResetPassword, payload + "." + strings.Repeat("A", len(signature))},
+		{"signed with another secret", TokenResetPassword, otherToken},
+		{"expired", TokenResetPassword, expiredPayload + "." + ts.sign(expiredPayload)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ts.Consume(ctx, tt.purpose, tt.token); !errors.Is(err, repositories.ErrInvalidToken) {
+				t.Errorf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	// None of the rejected attempts used the valid token up.
+	if _, err = ts.Consume(ctx, TokenResetPassword, valid); err != nil {
+		t.Errorf("valid token after rejected attempts: %v", err)
+	}
+}
+
+func TestTokenServiceIssueChecksInput(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewTokenService(nil, nil).Issue(ctx, TokenVerifyEmail, "user-1", "", time.Hour); err == nil {
+		t.Error("issued a token without a secret")
+	}
+	ts := newTestTokenService()
+	if _, err := ts.Issue(ctx, TokenVerifyEmail, "", "", time.Hour); err == nil {
+		t.Error("issued a token without a user")
+	}
+	if _, err := ts.Issue(ctx, TokenVerifyEmail, "user-1", "", 0); err == nil {
+		t.Error("issued a token without a ttl")
+	}
+}
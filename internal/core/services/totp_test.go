@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC lists 8-digit codes; these are their last 6 digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		counter := tt.unix / int64(totpPeriod.Seconds())
+		got, err := totpCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("totpCode(%d): %v", counter, err)
+		}
+		if got != tt.code {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := totpCode("not base32!", 1); err == nil {
+		t.Error("totpCode accepted an invalid secret")
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	code := func(counter int64) string {
+		c, err := totpCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		lastCounter int64
+		wantCounter int64
+		wantOK      bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"previous step", code(current - 1), 0, current - 1, true},
+		{"next step", code(current + 1), 0, current + 1, true},
+		{"too old", code(current - 2), 0, 0, false},
+		{"too new", code(current + 2), 0, 0, false},
+		{"replayed", code(current), current, 0, false},
+		{"older than last used", code(current - 1), current - 1, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := matchTOTP(rfc6238Secret, tt.code, now, tt.lastCounter)
+			if ok != tt.wantOK || counter != tt.wantCounter {
+				t.Errorf("matchTOTP = %d, %v; want %d, %v", counter, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
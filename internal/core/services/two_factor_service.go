@@ -0,0 +1,285 @@
+package services
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount     = 10
+	challengeTTL          = 5 * time.Minute
+	maxChallengeAttempts  = 5
+	recoveryCodeAlphabet  = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeHalfChars = 5
+)
+
+// TwoFactorService manages TOTP enrolment and the second login step.
+// Required makes two-factor authentication mandatory for every account;
+// users without it are asked to enrol during their next login.
+type TwoFactorService struct {
+	TwoFactor repositories.TwoFactorRepository
+	Issuer    string // shown by authenticator apps next to the account
+	Required  bool
+}
+
+func NewTwoFactorService(twoFactor repositories.TwoFactorRepository, issuer string, required bool) *TwoFactorService {
+	return &TwoFactorService{
+		TwoFactor: twoFactor,
+		Issuer:    issuer,
+		Required:  required,
+	}
+}
+
+func (ts *TwoFactorService) IsEnabled(ctx context.Context, userID domain.ID) (bool, error) {
+	twoFactor, err := ts.find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTwoFactorNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// BeginEnrollment generates a new secret for the user and returns the
+// provisioning URI to show as a QR code. Enrolment only takes effect once
+// ConfirmEnrollment accepts a code from the authenticator.
+func (ts *TwoFactorService) BeginEnrollment(ctx context.Context, user domain.User) (string, error) {
+	enabled, err := ts.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if enabled {
+		return "", domain.Conflict("two-factor authentication is already enabled")
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return "", domain.Wrap(err, "failed to generate secret")
+	}
+	if err = ts.TwoFactor.SaveTwoFactor(ctx, domain.TwoFactor{UserID: user.ID, Secret: secret}); err != nil {
+		return "", domain.Wrap(err, "failed to save two-factor secret")
+	}
+	return totpURI(ts.Issuer, user.Username, secret), nil
+}
+
+// ConfirmEnrollment enables two-factor authentication if the code matches
+// the pending secret, and returns the recovery codes, which are only shown
+// this once.
+func (ts *TwoFactorService) ConfirmEnrollment(ctx context.Context, userID domain.ID, code string) ([]string, error) {
+	twoFactor, err := ts.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, domain.Conflict("two-factor authentication is already enabled")
+	}
+	counter, ok := matchTOTP(twoFactor.Secret, normalizeCode(code), time.Now(), twoFactor.LastCounter)
+	if !ok {
+		return nil, repositories.ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to generate recovery codes")
+	}
+	now := time.Now()
+	twoFactor.Enabled = true
+	twoFactor.EnabledTime = &now
+	twoFactor.LastCounter = counter
+	twoFactor.RecoveryCodes = hashes
+	if err = ts.TwoFactor.SaveTwoFactor(ctx, twoFactor); err != nil {
+		return nil, domain.Wrap(err, "failed to enable two-factor authentication")
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a code.
+func (ts *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID domain.ID, code string) ([]string, error) {
+	if err := ts.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	twoFactor, err := ts.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to generate recovery codes")
+	}
+	twoFactor.RecoveryCodes = hashes
+	if err = ts.TwoFactor.SaveTwoFactor(ctx, twoFactor); err != nil {
+		return nil, domain.Wrap(err, "failed to save recovery codes")
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a code. It is
+// refused while two-factor authentication is required.
+func (ts *TwoFactorService) Disable(ctx context.Context, userID domain.ID, code string) error {
+	if ts.Required {
+		return domain.Forbidden("two-factor authentication is required and cannot be disabled")
+	}
+	if err := ts.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := ts.TwoFactor.DeleteTwoFactor(ctx, userID); err != nil {
+		return domain.Wrap(err, "failed to disable two-factor authentication")
+	}
+	return nil
+}
+
+// Verify accepts either a current authenticator code or an unused recovery
+// code, which is then consumed.
+func (ts *TwoFactorService) Verify(ctx context.Context, userID domain.ID, code string) error {
+	twoFactor, err := ts.find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled {
+		return repositories.ErrTwoFactorNotFound
+	}
+	code = normalizeCode(code)
+
+	if counter, ok := matchTOTP(twoFactor.Secret, code, time.Now(), twoFactor.LastCounter); ok {
+		twoFactor.LastCounter = counter
+		if err = ts.TwoFactor.SaveTwoFactor(ctx, twoFactor); err != nil {
+			return domain.Wrap(err, "failed to save two-factor state")
+		}
+		return nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, stored := range twoFactor.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) != 1 {
+			continue
+		}
+		twoFactor.RecoveryCodes = append(twoFactor.RecoveryCodes[:i], twoFactor.RecoveryCodes[i+1:]...)
+		if err = ts.TwoFactor.SaveTwoFactor(ctx, twoFactor); err != nil {
+			return domain.Wrap(err, "failed to use recovery code")
+		}
+		return nil
+	}
+	return repositories.ErrInvalidCode
+}
+
+// CreateChallenge starts the second login step. provisioningURI is set when
+// the user has to enrol as part of this login.
+func (ts *TwoFactorService) CreateChallenge(ctx context.Context, userID domain.ID, provisioningURI string) (domain.LoginChallenge, error) {
+	expires := time.Now().Add(challengeTTL)
+	challenge := domain.LoginChallenge{
+		ID:              domain.ID(uuid.New().String()),
+		UserID:          userID,
+		ProvisioningURI: provisioningURI,
+		ExpiresTime:     &expires,
+	}
+	if err := ts.TwoFactor.CreateChallenge(ctx, challenge); err != nil {
+		return domain.LoginChallenge{}, domain.Wrap(err, "failed to create login challenge")
+	}
+	return challenge, nil
+}
+
+// FindChallenge returns an unexpired login challenge.
+func (ts *TwoFactorService) FindChallenge(ctx context.Context, challengeID domain.ID) (domain.LoginChallenge, error) {
+	challenge, err := ts.TwoFactor.FindChallenge(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrChallengeNotFound) {
+			return domain.LoginChallenge{}, repositories.ErrChallengeNotFound
+		}
+		return domain.LoginChallenge{}, domain.Wrap(err, "failed to find login challenge")
+	}
+	if challenge.ExpiresTime != nil && !time.Now().Before(*challenge.ExpiresTime) {
+		_ = ts.TwoFactor.DeleteChallenge(ctx, challengeID)
+		return domain.LoginChallenge{}, repositories.ErrChallengeNotFound
+	}
+	return challenge, nil
+}
+
+// CompleteChallenge checks the code against the challenge, enrolling the
+// user first if the challenge asked for it. The challenge is deleted once
+// it succeeds, expires or runs out of attempts. Recovery codes are only
+// returned for enrolments.
+func (ts *TwoFactorService) CompleteChallenge(ctx context.Context, challengeID domain.ID, code string) (domain.ID, []string, error) {
+	challenge, err := ts.FindChallenge(ctx, challengeID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var recoveryCodes []string
+	if challenge.ProvisioningURI != "" {
+		recoveryCodes, err = ts.ConfirmEnrollment(ctx, challenge.UserID, code)
+	} else {
+		err = ts.Verify(ctx, challenge.UserID, code)
+	}
+	if err != nil {
+		if !errors.Is(err, repositories.ErrInvalidCode) {
+			return "", nil, err
+		}
+		challenge.Attempts++
+		if challenge.Attempts >= maxChallengeAttempts {
+			_ = ts.TwoFactor.DeleteChallenge(ctx, challengeID)
+			return "", nil, domain.Wrap(err, "too many attempts, log in again")
+		}
+		if updateErr := ts.TwoFactor.UpdateChallenge(ctx, challenge); updateErr != nil {
+			return "", nil, domain.Wrap(updateErr, "failed to update login challenge")
+		}
+		return "", nil, err
+	}
+
+	if err = ts.TwoFactor.DeleteChallenge(ctx, challengeID); err != nil {
+		return "", nil, domain.Wrap(err, "failed to delete login challenge")
+	}
+	return challenge.UserID, recoveryCodes, nil
+}
+
+func (ts *TwoFactorService) find(ctx context.Context, userID domain.ID) (domain.TwoFactor, error) {
+	if userID == "" {
+		return domain.TwoFactor{}, domain.InvalidArgument("userID cannot be empty")
+	}
+	twoFactor, err := ts.TwoFactor.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTwoFactorNotFound) {
+			return domain.TwoFactor{}, repositories.ErrTwoFactorNotFound
+		}
+		return domain.TwoFactor{}, domain.Wrap(err, "failed to find two-factor settings")
+	}
+	return twoFactor, nil
+}
+
+// newRecoveryCodes returns the codes to show the user and the hashes to store.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for range recoveryCodeCount {
+		b := make([]byte, 2*recoveryCodeHalfChars)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for i := range b {
+			b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+		}
+		code := string(b[:recoveryCodeHalfChars]) + "-" + string(b[recoveryCodeHalfChars:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(normalizeCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops the spaces and dashes users type or paste, so that
+// "123 456" and "abcde-fghjk" are accepted.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -3,100 +3,545 @@ package services
 import (
 	"chat-app/internal/core/domain"
 	"chat-app/internal/core/repositories"
+	"context"
 	"errors"
-	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// DefaultAccountDeletionGracePeriod is how long a deleted account can be
+// recovered by logging in before it is anonymized.
+const DefaultAccountDeletionGracePeriod = 30 * 24 * time.Hour
+
 type UserService struct {
-	User repositories.UserRepository
+	User                repositories.UserRepository
+	DeletionGracePeriod time.Duration
 }
 
 func NewUserService(user repositories.UserRepository) *UserService {
-	return &UserService{User: user}
+	return &UserService{
+		User:                user,
+		DeletionGracePeriod: DefaultAccountDeletionGracePeriod,
+	}
 }
 
+// ValidateUser checks a new user and reports every invalid field at once.
 func ValidateUser(user domain.User) error {
-	if user.ID == "" {
-		return fmt.Errorf("ID is required")
-	}
-	if user.Username == "" {
-		return fmt.Errorf("username is required")
+	var v domain.Validator
+	v.Required("id", string(user.ID))
+	v.Username("username", user.Username)
+	v.Name("first_name", user.FirstName, domain.MaxNameLength)
+	v.Name("last_name", user.LastName, domain.MaxNameLength)
+	v.Password("password", user.Password)
+	v.Check(user.Gender >= domain.Male && user.Gender <= domain.NonBinary, "gender", "is required")
+	v.Email("email", user.Email)
+	v.DateOfBirth("date_of_birth", user.DateOfBirth, time.Now())
+	return v.Err()
+}
+
+func (us *UserService) Register(ctx context.Context, user domain.User) (userID domain.ID, err error) {
+	if err = ValidateUser(user); err != nil {
+		return "", err
 	}
 
-	if user.FirstName == "" {
-		return fmt.Errorf("first name is required")
+	userID, err = us.User.Register(ctx, user)
+	if err != nil {
+		return "", domain.Wrap(err, "failed to register user")
 	}
-	if user.LastName == "" {
-		return fmt.Errorf("last name is required")
+
+	return userID, nil
+}
+
+// ValidateCredentials checks that a login has a username and a password.
+func ValidateCredentials(username, password string) error {
+	var v domain.Validator
+	v.Required("username", username)
+	v.Check(password != "", "password", "is required")
+	return v.Err()
+}
+
+func (us *UserService) Login(ctx context.Context, username, password string) (domain.ID, error) {
+	if err := ValidateCredentials(username, password); err != nil {
+		return "", err
 	}
-	if user.Password == "" {
-		return fmt.Errorf("password is required")
+
+	userID, err := us.User.Login(ctx, username, password)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWrongLoginInfo) {
+			return "", domain.Wrap(err, "wrong login info")
+		}
+		return "", domain.Wrap(err, "failed to login")
 	}
 
-	if user.Gender == 0 {
-		return fmt.Errorf("gender is required")
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err = us.checkRestorable(user); err != nil {
+		return "", err
 	}
+	return userID, nil
+}
 
-	if user.Email == "" {
-		return fmt.Errorf("email is required")
+// RestoreAccount cancels a pending deletion of the account. It is called
+// once the user has fully logged in, including any second factor.
+func (us *UserService) RestoreAccount(ctx context.Context, userID domain.ID) error {
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
 	}
+	if user.DeletedTime == nil {
+		return nil
+	}
+	if err = us.checkRestorable(user); err != nil {
+		return err
+	}
+	if err = us.User.SetDeletedTime(ctx, userID, nil); err != nil {
+		return domain.Wrap(err, "failed to cancel account deletion")
+	}
+	return nil
+}
 
-	if user.DateOfBirth == nil {
-		return fmt.Errorf("date of birth is required")
+// checkRestorable rejects logins to accounts whose deletion grace period
+// has passed.
+func (us *UserService) checkRestorable(user domain.User) error {
+	if user.DeletedTime == nil {
+		return nil
+	}
+	if user.AnonymizedTime != nil || time.Since(*user.DeletedTime) > us.DeletionGracePeriod {
+		return domain.Wrap(repositories.ErrWrongLoginInfo, "account was deleted")
 	}
 	return nil
+}
+
+func (us *UserService) GetChatIDList(ctx context.Context, userID domain.ID) (chatList []string, err error) {
+	if userID == "" {
+		return nil, domain.InvalidArgument("user ID is required")
+	}
+	chatList, err = us.User.GetChatIDList(ctx, userID)
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to get chat list")
+	}
+	return chatList, nil
+}
 
+// GetUserInfo returns the user without their password.
+func (us *UserService) GetUserInfo(ctx context.Context, userID domain.ID) (domain.User, error) {
+	user, err := us.User.GetUserInfo(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return domain.User{}, domain.Wrap(err, "user not found")
+		}
+		return domain.User{}, domain.Wrap(err, "failed to get user info")
+	}
+	user.Password = ""
+	return user, nil
 }
 
-func (us *UserService) Register(user domain.User) (userID domain.ID, err error) {
-	if err = ValidateUser(user); err != nil {
-		return "", fmt.Errorf("missing user fields:%v", err)
+// FindUser looks a user up by email if identifier contains an @, and by
+// username otherwise.
+func (us *UserService) FindUser(ctx context.Context, identifier string) (domain.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return domain.User{}, domain.InvalidArgument("username or email is required")
 	}
 
-	userID, err = us.User.Register(user)
+	find := us.User.FindUserByUsername
+	if strings.Contains(identifier, "@") {
+		find = us.User.FindUserByEmail
+	}
+	user, err := find(ctx, identifier)
 	if err != nil {
-		return "", fmt.Errorf("failed to register user:%v", err)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return domain.User{}, domain.Wrap(repositories.ErrUserNotFound, "user %s not found", identifier)
+		}
+		return domain.User{}, domain.Wrap(err, "failed to find user")
 	}
+	user.Password = ""
+	return user, nil
+}
 
-	return userID, nil
+func (us *UserService) GetPublicProfile(ctx context.Context, userID domain.ID) (domain.PublicProfile, error) {
+	if userID == "" {
+		return domain.PublicProfile{}, domain.InvalidArgument("user ID is required")
+	}
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return domain.PublicProfile{}, err
+	}
+	return user.PublicProfile(), nil
 }
 
-func (us *UserService) Login(username, password string) (domain.ID, error) {
-	if username == "" {
-		return "", fmt.Errorf("username is required")
+const maxBioLength = 500
+
+// validateProfileUpdate checks the fields the update sets.
+func validateProfileUpdate(update domain.ProfileUpdate) error {
+	var v domain.Validator
+	if update.Username != nil {
+		v.Username("username", *update.Username)
 	}
-	if password == "" {
-		return "", fmt.Errorf("password is required")
+	if update.Email != nil {
+		v.Email("email", *update.Email)
+	}
+	if update.FirstName != nil {
+		v.Name("first_name", *update.FirstName, domain.MaxNameLength)
+	}
+	if update.LastName != nil {
+		v.Name("last_name", *update.LastName, domain.MaxNameLength)
 	}
+	if update.Avatar != nil {
+		v.Check(strings.HasPrefix(update.Avatar.ContentType, "image/"), "avatar", "must be an image")
+	}
+	if update.Bio != nil {
+		v.Text("bio", *update.Bio, maxBioLength)
+	}
+	if update.Privacy != nil {
+		switch update.Privacy.Discoverability {
+		case domain.DiscoverableByEveryone, domain.DiscoverableByContacts, domain.DiscoverableByEmailOnly, domain.NotDiscoverable:
+		default:
+			v.Check(false, "privacy.discoverability", "invalid value %v", update.Privacy.Discoverability)
+		}
+	}
+	if update.DateOfBirth != nil {
+		v.DateOfBirth("date_of_birth", update.DateOfBirth, time.Now())
+	}
+	return v.Err()
+}
 
-	userID, err := us.User.Login(username, password)
+func (us *UserService) UpdateProfile(ctx context.Context, userID domain.ID, update domain.ProfileUpdate) (domain.User, error) {
+	if err := validateProfileUpdate(update); err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := us.GetUserInfo(ctx, userID)
 	if err != nil {
-		if errors.Is(err, repositories.ErrWrongLoginInfo) {
-			return "", fmt.Errorf("wrong login info: %v", err)
+		return domain.User{}, err
+	}
+
+	if update.Username != nil && *update.Username != user.Username {
+		if err = us.checkAvailable(ctx, us.User.FindUserByUsername, *update.Username, userID); err != nil {
+			return domain.User{}, domain.Wrap(err, "username %s", *update.Username)
 		}
-		return "", fmt.Errorf("failed to login:%v", err)
+		user.Username = *update.Username
 	}
-	return userID, nil
+	if update.Email != nil && !strings.EqualFold(*update.Email, user.Email) {
+		if err = us.checkAvailable(ctx, us.User.FindUserByEmail, *update.Email, userID); err != nil {
+			return domain.User{}, domain.Wrap(err, "email %s", *update.Email)
+		}
+		user.Email = *update.Email
+		user.EmailVerifiedTime = nil
+	}
+	if update.FirstName != nil {
+		user.FirstName = strings.TrimSpace(*update.FirstName)
+	}
+	if update.LastName != nil {
+		user.LastName = strings.TrimSpace(*update.LastName)
+	}
+	if update.Avatar != nil {
+		user.Avatar = update.Avatar
+	}
+	if update.RemoveAvatar {
+		user.Avatar = nil
+	}
+	if update.Bio != nil {
+		user.Bio = *update.Bio
+	}
+	if update.Privacy != nil {
+		user.Privacy = *update.Privacy
+	}
+	if update.DateOfBirth != nil {
+		user.DateOfBirth = update.DateOfBirth
+	}
+
+	if err = us.User.UpdateUser(ctx, user); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateUser) {
+			return domain.User{}, err
+		}
+		return domain.User{}, domain.Wrap(err, "failed to update profile")
+	}
+	return user, nil
 }
 
-func (us *UserService) GetChatIDList(userID domain.ID) (chatList []string, err error) {
-	if userID == "" {
-		return nil, fmt.Errorf("user ID is required")
+// checkAvailable fails with ErrDuplicateUser if another user already uses value.
+func (us *UserService) checkAvailable(ctx context.Context, find func(context.Context, string) (domain.User, error), value string, userID domain.ID) error {
+	existing, err := find(ctx, value)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return domain.Wrap(err, "failed to check availability")
+	}
+	if existing.ID != userID {
+		return repositories.ErrDuplicateUser
+	}
+	return nil
+}
+
+func ValidatePassword(password string) error {
+	var v domain.Validator
+	v.Password("password", password)
+	return v.Err()
+}
+
+// ChangePassword sets a new password after verifying the current one.
+func (us *UserService) ChangePassword(ctx context.Context, userID domain.ID, currentPassword, newPassword string) error {
+	if currentPassword == "" {
+		return domain.InvalidArgument("current password is required")
 	}
-	chatList, err = us.User.GetChatIDList(userID)
+	if err := ValidatePassword(newPassword); err != nil {
+		return err
+	}
+	if newPassword == currentPassword {
+		return domain.InvalidArgument("new password must differ from the current one")
+	}
+
+	user, err := us.GetUserInfo(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chat list:%v", err)
+		return err
 	}
-	return chatList, nil
+	verifiedID, err := us.User.Login(ctx, user.Username, currentPassword)
+	if err != nil || verifiedID != userID {
+		return domain.Wrap(repositories.ErrWrongLoginInfo, "current password is wrong")
+	}
+
+	if err = us.User.UpdatePassword(ctx, userID, newPassword); err != nil {
+		return domain.Wrap(err, "failed to change password")
+	}
+	return nil
 }
 
-func (us *UserService) GetUserInfo(userID domain.ID) (domain.User, error) {
-	user, err := us.User.GetUserInfo(userID)
+// ResetPassword sets a new password without the current one; the caller
+// must have verified the user some other way, e.g. with a reset token.
+func (us *UserService) ResetPassword(ctx context.Context, userID domain.ID, newPassword string) error {
+	if err := ValidatePassword(newPassword); err != nil {
+		return err
+	}
+	if _, err := us.GetUserInfo(ctx, userID); err != nil {
+		return err
+	}
+	if err := us.User.UpdatePassword(ctx, userID, newPassword); err != nil {
+		return domain.Wrap(err, "failed to reset password")
+	}
+	return nil
+}
+
+// MarkEmailVerified records that the user owns email. It fails if the user
+// has changed their address since the verification was requested.
+func (us *UserService) MarkEmailVerified(ctx context.Context, userID domain.ID, email string) error {
+	user, err := us.GetUserInfo(ctx, userID)
 	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return domain.User{}, fmt.Errorf("%w:%v", repositories.ErrUserNotFound, err)
+		return err
+	}
+	if !strings.EqualFold(user.Email, email) {
+		return domain.Wrap(repositories.ErrInvalidToken, "email has changed since verification was requested")
+	}
+	if user.EmailVerifiedTime != nil {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedTime = &now
+	if err = us.User.UpdateUser(ctx, user); err != nil {
+		return domain.Wrap(err, "failed to verify email")
+	}
+	return nil
+}
+
+// CheckEmailVerified fails with ErrEmailNotVerified until the user has
+// verified their email address.
+func (us *UserService) CheckEmailVerified(ctx context.Context, userID domain.ID) error {
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedTime == nil {
+		return repositories.ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (us *UserService) BlockUser(ctx context.Context, userID, blockedID domain.ID) error {
+	if blockedID == "" {
+		return domain.InvalidArgument("user ID to block is required")
+	}
+	if blockedID == userID {
+		return domain.InvalidArgument("cannot block yourself")
+	}
+	if _, err := us.GetUserInfo(ctx, blockedID); err != nil {
+		return err
+	}
+	if err := us.User.BlockUser(ctx, userID, blockedID); err != nil {
+		return domain.Wrap(err, "failed to block user")
+	}
+	return nil
+}
+
+func (us *UserService) UnblockUser(ctx context.Context, userID, blockedID domain.ID) error {
+	if blockedID == "" {
+		return domain.InvalidArgument("user ID to unblock is required")
+	}
+	if err := us.User.UnblockUser(ctx, userID, blockedID); err != nil {
+		return domain.Wrap(err, "failed to unblock user")
+	}
+	return nil
+}
+
+func (us *UserService) ListBlocked(ctx context.Context, userID domain.ID) ([]domain.PublicProfile, error) {
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []domain.PublicProfile
+	for _, blockedID := range user.Blocked {
+		profile, err := us.GetPublicProfile(ctx, blockedID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				continue
+			}
+			return nil, err
 		}
-		return domain.User{}, fmt.Errorf("failed to get user info:%v", err)
+		blocked = append(blocked, profile)
 	}
-	return user, nil
+	return blocked, nil
+}
+
+const (
+	defaultUserSearchLimit = 20
+	maxUserSearchLimit     = 100
+)
+
+// SearchUsers finds users by username prefix, display name or exact email,
+// leaving out deleted users, users blocked either way and users whose
+// privacy settings hide them from the searcher.
+func (us *UserService) SearchUsers(ctx context.Context, searcher domain.User, query domain.UserSearchQuery) (domain.UserSearchPage, error) {
+	text := strings.TrimSpace(query.Text)
+	if text == "" {
+		return domain.UserSearchPage{}, domain.InvalidArgument("search text is required")
+	}
+	if query.Offset < 0 {
+		return domain.UserSearchPage{}, domain.InvalidArgument("offset cannot be negative")
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultUserSearchLimit
+	}
+	limit = min(limit, maxUserSearchLimit)
+
+	if strings.Contains(text, "@") {
+		user, err := us.User.FindUserByEmail(ctx, text)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return domain.UserSearchPage{}, nil
+			}
+			return domain.UserSearchPage{}, domain.Wrap(err, "failed to search users")
+		}
+		if query.Offset > 0 || !user.CanBeFoundBy(searcher, true) {
+			return domain.UserSearchPage{}, nil
+		}
+		return domain.UserSearchPage{Users: []domain.PublicProfile{user.PublicProfile()}}, nil
+	}
+
+	// Filtering happens after the repository pages, so keep fetching until
+	// the page is full or the repository runs out.
+	var page domain.UserSearchPage
+	offset := query.Offset
+	for len(page.Users) < limit {
+		if err := ctx.Err(); err != nil {
+			return domain.UserSearchPage{}, err
+		}
+		users, err := us.User.SearchUsers(ctx, text, limit, offset)
+		if err != nil {
+			return domain.UserSearchPage{}, domain.Wrap(err, "failed to search users")
+		}
+		for i, user := range users {
+			if !user.CanBeFoundBy(searcher, false) {
+				continue
+			}
+			page.Users = append(page.Users, user.PublicProfile())
+			if len(page.Users) == limit {
+				page.NextOffset = offset + i + 1
+				return page, nil
+			}
+		}
+		if len(users) < limit {
+			return page, nil
+		}
+		offset += len(users)
+	}
+	return page, nil
+}
+
+// DeleteAccount marks the account as deleted after verifying the password.
+// The user can cancel by logging in again until DeletionGracePeriod has
+// passed, after which AnonymizeUser removes their personal data.
+func (us *UserService) DeleteAccount(ctx context.Context, userID domain.ID, password string) error {
+	if password == "" {
+		return domain.InvalidArgument("password is required")
+	}
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedTime != nil {
+		return domain.Conflict("account is already deleted")
+	}
+	verifiedID, err := us.User.Login(ctx, user.Username, password)
+	if err != nil || verifiedID != userID {
+		return domain.Wrap(repositories.ErrWrongLoginInfo, "password is wrong")
+	}
+
+	now := time.Now()
+	if err = us.User.SetDeletedTime(ctx, userID, &now); err != nil {
+		return domain.Wrap(err, "failed to delete account")
+	}
+	return nil
+}
+
+// ListExpiredDeletedUsers returns deleted users whose grace period has passed
+// and who still have to be anonymized.
+func (us *UserService) ListExpiredDeletedUsers(ctx context.Context) ([]domain.User, error) {
+	users, err := us.User.ListDeletedUsers(ctx, time.Now().Add(-us.DeletionGracePeriod))
+	if err != nil {
+		return nil, domain.Wrap(err, "failed to list deleted users")
+	}
+	return users, nil
+}
+
+// AnonymizeUser strips the personal data of a deleted user. The record
+// itself is kept so that messages and chat histories still resolve, under
+// domain.DeletedUserName.
+func (us *UserService) AnonymizeUser(ctx context.Context, userID domain.ID) error {
+	user, err := us.GetUserInfo(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedTime == nil {
+		return domain.InvalidArgument("only deleted accounts can be anonymized")
+	}
+
+	now := time.Now()
+	user.Username = "deleted-" + string(user.ID)
+	user.FirstName = ""
+	user.LastName = ""
+	user.Email = ""
+	user.Gender = 0
+	user.Avatar = nil
+	user.Bio = ""
+	user.DateOfBirth = nil
+	user.Contacts = nil
+	user.Blocked = nil
+	user.Privacy = domain.PrivacySettings{Discoverability: domain.NotDiscoverable}
+	user.AnonymizedTime = &now
+
+	if err = us.User.UpdateUser(ctx, user); err != nil {
+		return domain.Wrap(err, "failed to anonymize user")
+	}
+	// Nobody knows the new password, so the account can never be logged into.
+	if err = us.User.UpdatePassword(ctx, userID, uuid.New().String()); err != nil {
+		return domain.Wrap(err, "failed to anonymize user")
+	}
+	return nil
 }
@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileSink writes every mail to its own .eml file in Dir instead of sending
+// it, for local development.
+type FileSink struct {
+	Dir  string
+	From string
+}
+
+func NewFileSink(dir, from string) *FileSink {
+	return &FileSink{Dir: dir, From: from}
+}
+
+func (s *FileSink) Send(ctx context.Context, mail domain.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %v", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New().String())
+	if err := os.WriteFile(filepath.Join(s.Dir, name), format(s.From, mail), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %v", err)
+	}
+	return nil
+}
+
+// MemorySink keeps sent mail in memory, for tests.
+type MemorySink struct {
+	mu   sync.Mutex
+	sent []domain.Mail
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Send(_ context.Context, mail domain.Mail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, mail)
+	return nil
+}
+
+// Sent returns a copy of all mail sent so far, oldest first.
+func (s *MemorySink) Sent() []domain.Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.Mail(nil), s.sent...)
+}
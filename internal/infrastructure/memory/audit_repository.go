@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// AuditRepository implements repositories.AuditRepository and
+// repositories.EventPublisher, keeping published events in order.
+type AuditRepository struct {
+	mu        sync.Mutex
+	records   []domain.Event
+	published []domain.Event
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) AddRecord(_ context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, cloneEvent(event))
+	return nil
+}
+
+func (r *AuditRepository) ListRecords(_ context.Context, chatID domain.ID) ([]domain.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.Event
+	for _, event := range r.records {
+		if event.ChatID == chatID {
+			events = append(events, cloneEvent(event))
+		}
+	}
+	return events, nil
+}
+
+func (r *AuditRepository) Publish(_ context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, cloneEvent(event))
+	return nil
+}
+
+// Published returns the events published so far, oldest first.
+func (r *AuditRepository) Published() []domain.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.published)
+}
+
+func cloneEvent(event domain.Event) domain.Event {
+	event.Details = maps.Clone(event.Details)
+	return event
+}
@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChatRepository implements repositories.ChatRepository. Messages are read
+// from the MessageRepository the chats are stored with.
+type ChatRepository struct {
+	mu       sync.Mutex
+	chats    map[domain.ID]domain.Chat
+	messages *MessageRepository
+}
+
+func NewChatRepository(messages *MessageRepository) *ChatRepository {
+	return &ChatRepository{
+		chats:    make(map[domain.ID]domain.Chat),
+		messages: messages,
+	}
+}
+
+func (r *ChatRepository) CreateChat(_ context.Context, chat domain.Chat) (domain.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.chats[chat.ID]; ok {
+		return "", repositories.ErrDuplicateChat
+	}
+	if chat.ChatType == domain.Private {
+		for _, existing := range r.chats {
+			if existing.ChatType == domain.Private && existing.DeletedTime == nil && existing.Name == chat.Name {
+				return "", repositories.ErrDuplicateChat
+			}
+		}
+	}
+	r.chats[chat.ID] = cloneChat(chat)
+	return chat.ID, nil
+}
+
+func (r *ChatRepository) FindChat(_ context.Context, chatID domain.ID) (domain.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return domain.Chat{}, repositories.ErrChatNotFound
+	}
+	return cloneChat(chat), nil
+}
+
+func (r *ChatRepository) FindPrivateChat(_ context.Context, userID, otherUserID domain.ID) (domain.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, chat := range r.chats {
+		if chat.ChatType != domain.Private || chat.DeletedTime != nil {
+			continue
+		}
+		if slices.Contains(chat.Members, userID) && slices.Contains(chat.Members, otherUserID) {
+			return cloneChat(chat), nil
+		}
+	}
+	return domain.Chat{}, repositories.ErrChatNotFound
+}
+
+func (r *ChatRepository) UpdateChat(_ context.Context, chat domain.Chat) error {
+	return r.update(chat.ID, func(stored *domain.Chat) error {
+		updated := cloneChat(chat)
+		stored.Name = updated.Name
+		stored.Description = updated.Description
+		stored.Topic = updated.Topic
+		stored.Avatar = updated.Avatar
+		stored.Settings = updated.Settings
+		stored.Permissions = updated.Permissions
+		return nil
+	})
+}
+
+func (r *ChatRepository) SetDeletedTime(_ context.Context, chatID domain.ID, deletedTime *time.Time) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		chat.DeletedTime = deletedTime
+		return nil
+	})
+}
+
+func (r *ChatRepository) ListDeletedChats(_ context.Context, deletedBefore time.Time) ([]domain.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var chats []domain.Chat
+	for _, chat := range r.chats {
+		if chat.DeletedTime != nil && chat.DeletedTime.Before(deletedBefore) {
+			chats = append(chats, cloneChat(chat))
+		}
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ID < chats[j].ID })
+	return chats, nil
+}
+
+func (r *ChatRepository) DeleteChat(_ context.Context, chatID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.chats[chatID]; !ok {
+		return repositories.ErrChatNotFound
+	}
+	delete(r.chats, chatID)
+	return nil
+}
+
+func (r *ChatRepository) GetMessages(_ context.Context, chatID domain.ID) ([]domain.Message, error) {
+	r.mu.Lock()
+	_, ok := r.chats[chatID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, repositories.ErrChatNotFound
+	}
+	return r.messages.chatMessages(chatID), nil
+}
+
+func (r *ChatRepository) AddUser(_ context.Context, chatID domain.ID, userIDs []domain.ID) error {
+	now := time.Now()
+	return r.update(chatID, func(chat *domain.Chat) error {
+		for _, userID := range userIDs {
+			if slices.Contains(chat.Members, userID) {
+				continue
+			}
+			chat.Members = append(chat.Members, userID)
+			if chat.JoinedTimes == nil {
+				chat.JoinedTimes = make(map[domain.ID]time.Time)
+			}
+			chat.JoinedTimes[userID] = now
+		}
+		return nil
+	})
+}
+
+func (r *ChatRepository) RemoveUser(_ context.Context, chatID domain.ID, userIDs []domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		for _, userID := range userIDs {
+			chat.Members = slices.DeleteFunc(chat.Members, func(id domain.ID) bool { return id == userID })
+			chat.Admins = slices.DeleteFunc(chat.Admins, func(id domain.ID) bool { return id == userID })
+			delete(chat.JoinedTimes, userID)
+		}
+		return nil
+	})
+}
+
+func (r *ChatRepository) GetMembers(_ context.Context, chatID domain.ID) ([]domain.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chat, ok := r.chats[chatID]
+	if !ok {
+		return nil, repositories.ErrChatNotFound
+	}
+	return slices.Clone(chat.Members), nil
+}
+
+func (r *ChatRepository) SetAdmin(_ context.Context, userID, chatID domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		if !chat.IsMember(userID) {
+			return repositories.ErrUserNotFound
+		}
+		if !slices.Contains(chat.Admins, userID) {
+			chat.Admins = append(chat.Admins, userID)
+		}
+		return nil
+	})
+}
+
+func (r *ChatRepository) RemoveAdmin(_ context.Context, userID, chatID domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		chat.Admins = slices.DeleteFunc(chat.Admins, func(id domain.ID) bool { return id == userID })
+		return nil
+	})
+}
+
+// SetOwner keeps the previous owner as a member; callers decide whether
+// they stay, e.g. as an admin, or leave.
+func (r *ChatRepository) SetOwner(_ context.Context, userID, chatID domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		if chat.Owner != "" && !slices.Contains(chat.Members, chat.Owner) {
+			chat.Members = append(chat.Members, chat.Owner)
+		}
+		chat.Owner = userID
+		chat.Admins = slices.DeleteFunc(chat.Admins, func(id domain.ID) bool { return id == userID })
+		return nil
+	})
+}
+
+func (r *ChatRepository) UpdatePermissions(_ context.Context, chatID domain.ID, permissions domain.ChatPermissions) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		chat.Permissions = clonePermissions(permissions)
+		return nil
+	})
+}
+
+func (r *ChatRepository) PinMessage(_ context.Context, chatID, messageID domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		if !slices.Contains(chat.Pinned, messageID) {
+			chat.Pinned = append(chat.Pinned, messageID)
+		}
+		return nil
+	})
+}
+
+func (r *ChatRepository) UnpinMessage(_ context.Context, chatID, messageID domain.ID) error {
+	return r.update(chatID, func(chat *domain.Chat) error {
+		chat.Pinned = slices.DeleteFunc(chat.Pinned, func(id domain.ID) bool { return id == messageID })
+		return nil
+	})
+}
+
+// chatIDsOf returns the IDs of the chats the user belongs to, including
+// deleted ones, in ID order.
+func (r *ChatRepository) chatIDsOf(userID domain.ID) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var chatIDs []string
+	for _, chat := range r.chats {
+		if chat.IsMember(userID) {
+			chatIDs = append(chatIDs, string(chat.ID))
+		}
+	}
+	sort.Strings(chatIDs)
+	return chatIDs
+}
+
+// update applies change to the stored chat under the lock, and saves it
+// unless change fails.
+func (r *ChatRepository) update(chatID domain.ID, change func(*domain.Chat) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.chats[chatID]
+	if !ok {
+		return repositories.ErrChatNotFound
+	}
+	chat := cloneChat(stored)
+	if err := change(&chat); err != nil {
+		return err
+	}
+	r.chats[chatID] = chat
+	return nil
+}
+
+// cloneChat copies the chat's slices and maps so that callers cannot change
+// the stored chat through them.
+func cloneChat(chat domain.Chat) domain.Chat {
+	chat.Admins = slices.Clone(chat.Admins)
+	chat.Members = slices.Clone(chat.Members)
+	chat.JoinedTimes = maps.Clone(chat.JoinedTimes)
+	chat.Pinned = slices.Clone(chat.Pinned)
+	chat.Permissions = clonePermissions(chat.Permissions)
+	return chat
+}
+
+func clonePermissions(permissions domain.ChatPermissions) domain.ChatPermissions {
+	cloned := domain.ChatPermissions{}
+	if permissions.Roles != nil {
+		cloned.Roles = make(map[string]domain.Permissions, len(permissions.Roles))
+		for role, granted := range permissions.Roles {
+			cloned.Roles[role] = maps.Clone(granted)
+		}
+	}
+	if permissions.Overrides != nil {
+		cloned.Overrides = make(map[domain.ID]domain.Permissions, len(permissions.Overrides))
+		for userID, granted := range permissions.Overrides {
+			cloned.Overrides[userID] = maps.Clone(granted)
+		}
+	}
+	return cloned
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ContactRepository implements repositories.ContactRepository. Contacts are
+// kept in the User.Contacts of the UserRepository they are stored with.
+type ContactRepository struct {
+	mu       sync.Mutex
+	requests map[domain.ID]domain.ContactRequest
+	users    *UserRepository
+}
+
+func NewContactRepository(users *UserRepository) *ContactRepository {
+	return &ContactRepository{
+		requests: make(map[domain.ID]domain.ContactRequest),
+		users:    users,
+	}
+}
+
+func (r *ContactRepository) AddContact(_ context.Context, userID, contactID domain.ID) error {
+	r.users.mu.Lock()
+	_, ok := r.users.users[contactID]
+	r.users.mu.Unlock()
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	return r.users.update(userID, func(user *domain.User) {
+		if !slices.Contains(user.Contacts, contactID) {
+			user.Contacts = append(user.Contacts, contactID)
+		}
+	})
+}
+
+func (r *ContactRepository) RemoveContact(_ context.Context, userID, contactID domain.ID) error {
+	return r.users.update(userID, func(user *domain.User) {
+		user.Contacts = slices.DeleteFunc(user.Contacts, func(id domain.ID) bool { return id == contactID })
+	})
+}
+
+func (r *ContactRepository) CreateContactRequest(_ context.Context, request domain.ContactRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[request.ID] = request
+	return nil
+}
+
+func (r *ContactRepository) FindContactRequest(_ context.Context, fromUserID, toUserID domain.ID) (domain.ContactRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, request := range r.requests {
+		if request.FromUserID == fromUserID && request.ToUserID == toUserID {
+			return request, nil
+		}
+	}
+	return domain.ContactRequest{}, repositories.ErrContactRequestNotFound
+}
+
+func (r *ContactRepository) ListContactRequests(_ context.Context, toUserID domain.ID) ([]domain.ContactRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var requests []domain.ContactRequest
+	for _, request := range r.requests {
+		if request.ToUserID == toUserID {
+			requests = append(requests, request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return createdBefore(requests[i].CreatedTime, requests[j].CreatedTime, requests[i].ID < requests[j].ID)
+	})
+	return requests, nil
+}
+
+func (r *ContactRepository) DeleteContactRequest(_ context.Context, requestID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.requests[requestID]; !ok {
+		return repositories.ErrContactRequestNotFound
+	}
+	delete(r.requests, requestID)
+	return nil
+}
+
+func (r *ContactRepository) DeleteUserContacts(_ context.Context, userID domain.ID) error {
+	r.mu.Lock()
+	for requestID, request := range r.requests {
+		if request.FromUserID == userID || request.ToUserID == userID {
+			delete(r.requests, requestID)
+		}
+	}
+	r.mu.Unlock()
+
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+	for id, user := range r.users.users {
+		if id == userID {
+			user.Contacts = nil
+		} else if slices.Contains(user.Contacts, userID) {
+			user = cloneUser(user)
+			user.Contacts = slices.DeleteFunc(user.Contacts, func(contactID domain.ID) bool { return contactID == userID })
+		} else {
+			continue
+		}
+		r.users.users[id] = user
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExportRepository implements repositories.ExportRepository.
+type ExportRepository struct {
+	mu       sync.Mutex
+	exports  map[domain.ID]domain.DataExport
+	archives map[domain.ID][]byte
+}
+
+func NewExportRepository() *ExportRepository {
+	return &ExportRepository{
+		exports:  make(map[domain.ID]domain.DataExport),
+		archives: make(map[domain.ID][]byte),
+	}
+}
+
+func (r *ExportRepository) CreateExport(_ context.Context, export domain.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exports[export.ID] = export
+	return nil
+}
+
+func (r *ExportRepository) FindExport(_ context.Context, exportID domain.ID) (domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export, ok := r.exports[exportID]
+	if !ok {
+		return domain.DataExport{}, repositories.ErrExportNotFound
+	}
+	return export, nil
+}
+
+func (r *ExportRepository) UpdateExport(_ context.Context, export domain.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.exports[export.ID]; !ok {
+		return repositories.ErrExportNotFound
+	}
+	r.exports[export.ID] = export
+	return nil
+}
+
+func (r *ExportRepository) MarkExportDownloaded(_ context.Context, exportID domain.ID, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export, ok := r.exports[exportID]
+	if !ok {
+		return repositories.ErrExportNotFound
+	}
+	if !export.IsDownloadable(now) {
+		return repositories.ErrExportNotAvailable
+	}
+	export.Status = domain.ExportDownloaded
+	export.DownloadedTime = &now
+	r.exports[exportID] = export
+	return nil
+}
+
+func (r *ExportRepository) ListPendingExports(_ context.Context) ([]domain.DataExport, error) {
+	return r.list(func(export domain.DataExport) bool {
+		return export.Status == domain.ExportPending
+	}), nil
+}
+
+func (r *ExportRepository) ListExpiredExports(_ context.Context, before time.Time) ([]domain.DataExport, error) {
+	return r.list(func(export domain.DataExport) bool {
+		if _, ok := r.archives[export.ID]; !ok {
+			return false
+		}
+		return export.Status == domain.ExportDownloaded ||
+			(export.ExpiresTime != nil && export.ExpiresTime.Before(before))
+	}), nil
+}
+
+func (r *ExportRepository) SaveArchive(_ context.Context, exportID domain.ID, archive []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archives[exportID] = slices.Clone(archive)
+	return nil
+}
+
+func (r *ExportRepository) LoadArchive(_ context.Context, exportID domain.ID) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	archive, ok := r.archives[exportID]
+	if !ok {
+		return nil, repositories.ErrExportNotAvailable
+	}
+	return slices.Clone(archive), nil
+}
+
+func (r *ExportRepository) DeleteArchive(_ context.Context, exportID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.archives, exportID)
+	return nil
+}
+
+// list returns the exports keep accepts, oldest first. keep is called with
+// the lock held.
+func (r *ExportRepository) list(keep func(domain.DataExport) bool) []domain.DataExport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var exports []domain.DataExport
+	for _, export := range r.exports {
+		if keep(export) {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return createdBefore(exports[i].CreatedTime, exports[j].CreatedTime, exports[i].ID < exports[j].ID)
+	})
+	return exports
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InviteRepository implements repositories.InviteRepository.
+type InviteRepository struct {
+	mu       sync.Mutex
+	invites  map[string]domain.Invite
+	requests map[domain.ID]domain.JoinRequest
+}
+
+func NewInviteRepository() *InviteRepository {
+	return &InviteRepository{
+		invites:  make(map[string]domain.Invite),
+		requests: make(map[domain.ID]domain.JoinRequest),
+	}
+}
+
+func (r *InviteRepository) CreateInvite(_ context.Context, invite domain.Invite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invites[invite.Code] = invite
+	return nil
+}
+
+func (r *InviteRepository) FindInvite(_ context.Context, code string) (domain.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invite, ok := r.invites[code]
+	if !ok {
+		return domain.Invite{}, repositories.ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+func (r *InviteRepository) ListInvites(_ context.Context, chatID domain.ID) ([]domain.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var invites []domain.Invite
+	for _, invite := range r.invites {
+		if invite.ChatID == chatID {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return createdBefore(invites[i].CreatedTime, invites[j].CreatedTime, invites[i].Code < invites[j].Code)
+	})
+	return invites, nil
+}
+
+func (r *InviteRepository) UpdateInvite(_ context.Context, invite domain.Invite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.invites[invite.Code]; !ok {
+		return repositories.ErrInviteNotFound
+	}
+	r.invites[invite.Code] = invite
+	return nil
+}
+
+func (r *InviteRepository) IncrementInviteUsage(_ context.Context, code string, now time.Time) (domain.Invite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invite, ok := r.invites[code]
+	if !ok {
+		return domain.Invite{}, repositories.ErrInviteNotFound
+	}
+	if !invite.IsUsable(now) {
+		return domain.Invite{}, repositories.ErrInviteNotUsable
+	}
+	invite.UsageCount++
+	r.invites[code] = invite
+	return invite, nil
+}
+
+func (r *InviteRepository) CreateJoinRequest(_ context.Context, request domain.JoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.requests {
+		if existing.ChatID == request.ChatID && existing.UserID == request.UserID && existing.Status == domain.Pending {
+			return repositories.ErrJoinRequestPending
+		}
+	}
+	r.requests[request.ID] = request
+	return nil
+}
+
+func (r *InviteRepository) FindJoinRequest(_ context.Context, requestID domain.ID) (domain.JoinRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[requestID]
+	if !ok {
+		return domain.JoinRequest{}, repositories.ErrJoinRequestNotFound
+	}
+	return request, nil
+}
+
+func (r *InviteRepository) ListJoinRequests(_ context.Context, chatID domain.ID) ([]domain.JoinRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var requests []domain.JoinRequest
+	for _, request := range r.requests {
+		if request.ChatID == chatID {
+			requests = append(requests, request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return createdBefore(requests[i].CreatedTime, requests[j].CreatedTime, requests[i].ID < requests[j].ID)
+	})
+	return requests, nil
+}
+
+func (r *InviteRepository) UpdateJoinRequest(_ context.Context, request domain.JoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.requests[request.ID]; !ok {
+		return repositories.ErrJoinRequestNotFound
+	}
+	r.requests[request.ID] = request
+	return nil
+}
+
+// createdBefore orders records by creation time, falling back to tie for
+// records created at the same time or without one.
+func createdBefore(a, b *time.Time, tie bool) bool {
+	if a != nil && b != nil && !a.Equal(*b) {
+		return a.Before(*b)
+	}
+	return tie
+}
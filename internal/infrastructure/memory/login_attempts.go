@@ -0,0 +1,81 @@
+// Package memory holds in-memory stores for a single process, for local
+// development and tests.
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// LoginAttemptStore implements repositories.LoginAttemptStore.
+type LoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempts
+}
+
+func NewLoginAttemptStore() *LoginAttemptStore {
+	return &LoginAttemptStore{attempts: make(map[string]domain.LoginAttempts)}
+}
+
+func (s *LoginAttemptStore) GetLoginAttempts(_ context.Context, key string) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.attempts[key]
+	if !ok {
+		return domain.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+func (s *LoginAttemptStore) AddLoginFailure(_ context.Context, key string, now time.Time, window time.Duration) (domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts := s.attempts[key]
+	attempts.Key = key
+	if attempts.LastFailureTime != nil && now.Sub(*attempts.LastFailureTime) > window {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureTime = &now
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *LoginAttemptStore) LockLogin(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts := s.attempts[key]
+	attempts.Key = key
+	attempts.LockedUntil = &until
+	s.attempts[key] = attempts
+	return nil
+}
+
+func (s *LoginAttemptStore) ResetLoginAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// DeleteIdle drops records whose last failure and lock both ended before
+// before, and returns how many were dropped. Pass a time at least the
+// longest policy window ago and run it periodically to keep memory bounded.
+func (s *LoginAttemptStore) DeleteIdle(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int
+	for key, attempts := range s.attempts {
+		if attempts.LastFailureTime != nil && !attempts.LastFailureTime.Before(before) {
+			continue
+		}
+		if attempts.LockedUntil != nil && !attempts.LockedUntil.Before(before) {
+			continue
+		}
+		delete(s.attempts, key)
+		deleted++
+	}
+	return deleted
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MessageRepository implements repositories.MessageRepository. IDs are
+// assigned in sending order, so ID order is also time order.
+type MessageRepository struct {
+	mu       sync.Mutex
+	messages map[domain.ID]domain.Message
+	views    map[domain.ID]map[domain.ID]bool // messageID -> viewers
+	lastID   int
+}
+
+func NewMessageRepository() *MessageRepository {
+	return &MessageRepository{
+		messages: make(map[domain.ID]domain.Message),
+		views:    make(map[domain.ID]map[domain.ID]bool),
+	}
+}
+
+func (r *MessageRepository) SendMessage(ctx context.Context, chatID, userID domain.ID, message string) (domain.Message, error) {
+	return r.SendMedia(ctx, chatID, userID, message, nil)
+}
+
+func (r *MessageRepository) SendMedia(_ context.Context, chatID, userID domain.ID, caption string, attachments []domain.Attachment) (domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastID++
+	now := time.Now()
+	message := domain.Message{
+		ID:          domain.ID(fmt.Sprintf("%020d", r.lastID)),
+		SenderID:    userID,
+		ChatID:      chatID,
+		Content:     caption,
+		Attachments: slices.Clone(attachments),
+		CreatedTime: &now,
+	}
+	r.messages[message.ID] = message
+	return cloneMessage(message), nil
+}
+
+func (r *MessageRepository) FindMessage(_ context.Context, messageID domain.ID) (domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[messageID]
+	if !ok {
+		return domain.Message{}, repositories.ErrMessageNotFound
+	}
+	return cloneMessage(message), nil
+}
+
+func (r *MessageRepository) EditMessage(_ context.Context, chatID, _, messageID domain.ID, content string) (domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[messageID]
+	if !ok || message.ChatID != chatID {
+		return domain.Message{}, repositories.ErrMessageNotFound
+	}
+	now := time.Now()
+	message.Content = content
+	message.EditedTime = &now
+	r.messages[messageID] = message
+	return cloneMessage(message), nil
+}
+
+func (r *MessageRepository) DeleteMessage(_ context.Context, chatID, _, messageID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[messageID]
+	if !ok || message.ChatID != chatID {
+		return repositories.ErrMessageNotFound
+	}
+	r.delete(messageID)
+	return nil
+}
+
+func (r *MessageRepository) DeleteChatMessages(_ context.Context, chatID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for messageID, message := range r.messages {
+		if message.ChatID == chatID {
+			r.delete(messageID)
+		}
+	}
+	return nil
+}
+
+func (r *MessageRepository) DeleteUserMessages(_ context.Context, userID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for messageID, message := range r.messages {
+		if message.SenderID == userID {
+			r.delete(messageID)
+		}
+	}
+	return nil
+}
+
+func (r *MessageRepository) ListUserMessages(_ context.Context, userID domain.ID) ([]domain.Message, error) {
+	return r.list(func(message domain.Message) bool { return message.SenderID == userID }), nil
+}
+
+func (r *MessageRepository) ListMessages(_ context.Context, afterID domain.ID, limit int) ([]domain.Message, error) {
+	messages := r.list(func(message domain.Message) bool { return message.ID > afterID })
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (r *MessageRepository) AddView(_ context.Context, messageID, userID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[messageID]
+	if !ok {
+		return repositories.ErrMessageNotFound
+	}
+	viewers, ok := r.views[messageID]
+	if !ok {
+		viewers = make(map[domain.ID]bool)
+		r.views[messageID] = viewers
+	}
+	if viewers[userID] {
+		return nil
+	}
+	viewers[userID] = true
+	message.Views++
+	r.messages[messageID] = message
+	return nil
+}
+
+// chatMessages returns the chat's messages, oldest first.
+func (r *MessageRepository) chatMessages(chatID domain.ID) []domain.Message {
+	return r.list(func(message domain.Message) bool { return message.ChatID == chatID })
+}
+
+// list returns the messages accepted by keep in ID order.
+func (r *MessageRepository) list(keep func(domain.Message) bool) []domain.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []domain.Message
+	for _, message := range r.messages {
+		if keep(message) {
+			messages = append(messages, cloneMessage(message))
+		}
+	}
+	slices.SortFunc(messages, func(a, b domain.Message) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return messages
+}
+
+func (r *MessageRepository) delete(messageID domain.ID) {
+	delete(r.messages, messageID)
+	delete(r.views, messageID)
+}
+
+func cloneMessage(message domain.Message) domain.Message {
+	message.Attachments = slices.Clone(message.Attachments)
+	return message
+}
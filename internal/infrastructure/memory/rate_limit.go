@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitStore implements repositories.RateLimitStore.
+type RateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]domain.TokenBucket
+}
+
+func NewRateLimitStore() *RateLimitStore {
+	return &RateLimitStore{buckets: make(map[string]domain.TokenBucket)}
+}
+
+func (s *RateLimitStore) Take(_ context.Context, key string, limit domain.RateLimit, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, retryAfter := s.buckets[key].Take(limit, now)
+	s.buckets[key] = bucket
+	return retryAfter, nil
+}
+
+// DeleteIdle drops buckets untouched since before, which are full again by
+// then for any sensible limit, and returns how many were dropped. Run it
+// periodically to keep memory bounded.
+func (s *RateLimitStore) DeleteIdle(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int
+	for key, bucket := range s.buckets {
+		if bucket.UpdatedTime.Before(before) {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted
+}
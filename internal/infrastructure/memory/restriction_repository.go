@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RestrictionRepository implements repositories.RestrictionRepository.
+type RestrictionRepository struct {
+	mu           sync.Mutex
+	restrictions map[restrictionKey]domain.Restriction
+	lastPosts    map[memberKey]time.Time
+}
+
+type memberKey struct {
+	chatID, userID domain.ID
+}
+
+type restrictionKey struct {
+	memberKey
+	restrictionType domain.RestrictionType
+}
+
+func NewRestrictionRepository() *RestrictionRepository {
+	return &RestrictionRepository{
+		restrictions: make(map[restrictionKey]domain.Restriction),
+		lastPosts:    make(map[memberKey]time.Time),
+	}
+}
+
+func (r *RestrictionRepository) AddRestriction(_ context.Context, restriction domain.Restriction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.restrictions[keyOf(restriction.ChatID, restriction.UserID, restriction.Type)] = restriction
+	return nil
+}
+
+func (r *RestrictionRepository) FindRestriction(_ context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) (domain.Restriction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	restriction, ok := r.restrictions[keyOf(chatID, userID, restrictionType)]
+	if !ok {
+		return domain.Restriction{}, repositories.ErrRestrictionNotFound
+	}
+	return restriction, nil
+}
+
+func (r *RestrictionRepository) ListRestrictions(_ context.Context, chatID domain.ID) ([]domain.Restriction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var restrictions []domain.Restriction
+	for _, restriction := range r.restrictions {
+		if restriction.ChatID == chatID {
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	sort.Slice(restrictions, func(i, j int) bool {
+		a, b := restrictions[i], restrictions[j]
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.Type < b.Type
+	})
+	return restrictions, nil
+}
+
+func (r *RestrictionRepository) RemoveRestriction(_ context.Context, chatID, userID domain.ID, restrictionType domain.RestrictionType) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := keyOf(chatID, userID, restrictionType)
+	if _, ok := r.restrictions[key]; !ok {
+		return repositories.ErrRestrictionNotFound
+	}
+	delete(r.restrictions, key)
+	return nil
+}
+
+func (r *RestrictionRepository) DeleteExpiredRestrictions(_ context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int
+	for key, restriction := range r.restrictions {
+		if !restriction.IsActive(now) {
+			delete(r.restrictions, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *RestrictionRepository) ReservePost(_ context.Context, chatID, userID domain.ID, now time.Time, interval time.Duration) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := memberKey{chatID: chatID, userID: userID}
+	if last, ok := r.lastPosts[key]; ok {
+		if remaining := last.Add(interval).Sub(now); remaining > 0 {
+			return remaining, nil
+		}
+	}
+	r.lastPosts[key] = now
+	return 0, nil
+}
+
+func keyOf(chatID, userID domain.ID, restrictionType domain.RestrictionType) restrictionKey {
+	return restrictionKey{memberKey: memberKey{chatID: chatID, userID: userID}, restrictionType: restrictionType}
+}
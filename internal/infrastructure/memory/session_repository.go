@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionRepository implements repositories.SessionRepository. Expired
+// sessions are treated as missing.
+type SessionRepository struct {
+	mu       sync.Mutex
+	sessions map[domain.ID]*storedSession
+}
+
+type storedSession struct {
+	session     domain.Session
+	roles       map[domain.ID]string // chatID -> role
+	createdTime time.Time
+	expiresTime *time.Time // nil means the session never expires
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{sessions: make(map[domain.ID]*storedSession)}
+}
+
+func (r *SessionRepository) CreateSession(_ context.Context, session domain.Session, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	stored := &storedSession{
+		session:     cloneSession(session),
+		roles:       make(map[domain.ID]string),
+		createdTime: now,
+	}
+	if stored.session.ChatIDAndName == nil {
+		stored.session.ChatIDAndName = make(map[string]string)
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		stored.expiresTime = &expires
+	}
+	r.sessions[session.SessionID] = stored
+	return nil
+}
+
+func (r *SessionRepository) GetSession(_ context.Context, sessionID domain.ID) (domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.find(sessionID)
+	if !ok {
+		return domain.Session{}, repositories.ErrSessionNotFound
+	}
+	return cloneSession(stored.session), nil
+}
+
+// GetSessionByUserID returns the user's most recent session.
+func (r *SessionRepository) GetSessionByUserID(_ context.Context, userID domain.ID) (domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *storedSession
+	for sessionID, stored := range r.sessions {
+		if stored.session.UserID != userID {
+			continue
+		}
+		if _, ok := r.find(sessionID); !ok {
+			continue
+		}
+		if latest == nil || stored.createdTime.After(latest.createdTime) {
+			latest = stored
+		}
+	}
+	if latest == nil {
+		return domain.Session{}, repositories.ErrSessionNotFound
+	}
+	return cloneSession(latest.session), nil
+}
+
+func (r *SessionRepository) ListUserSessions(_ context.Context, userID domain.ID) ([]domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var live []*storedSession
+	for sessionID, stored := range r.sessions {
+		if stored.session.UserID != userID {
+			continue
+		}
+		if _, ok := r.find(sessionID); ok {
+			live = append(live, stored)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool {
+		if !live[i].createdTime.Equal(live[j].createdTime) {
+			return live[i].createdTime.Before(live[j].createdTime)
+		}
+		return live[i].session.SessionID < live[j].session.SessionID
+	})
+	sessions := make([]domain.Session, 0, len(live))
+	for _, stored := range live {
+		sessions = append(sessions, cloneSession(stored.session))
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepository) AddChatToSession(_ context.Context, sessionID, chatID domain.ID, chatName, role string) error {
+	return r.update(sessionID, func(stored *storedSession) {
+		stored.session.ChatIDAndName[string(chatID)] = chatName
+		stored.roles[chatID] = role
+	})
+}
+
+func (r *SessionRepository) RemoveChatFromSession(_ context.Context, sessionID, chatID domain.ID) error {
+	return r.update(sessionID, func(stored *storedSession) {
+		delete(stored.session.ChatIDAndName, string(chatID))
+		delete(stored.roles, chatID)
+	})
+}
+
+func (r *SessionRepository) UpdateChatRole(_ context.Context, sessionID, chatID domain.ID, role string) error {
+	return r.update(sessionID, func(stored *storedSession) {
+		if _, ok := stored.session.ChatIDAndName[string(chatID)]; ok {
+			stored.roles[chatID] = role
+		}
+	})
+}
+
+func (r *SessionRepository) IsUserInChat(_ context.Context, sessionID, chatID domain.ID) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.find(sessionID)
+	if !ok {
+		return "", repositories.ErrSessionNotFound
+	}
+	if _, ok = stored.session.ChatIDAndName[string(chatID)]; !ok {
+		return "", repositories.ErrUserNotFound
+	}
+	return stored.roles[chatID], nil
+}
+
+func (r *SessionRepository) DeleteSession(_ context.Context, sessionID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, sessionID)
+	return nil
+}
+
+func (r *SessionRepository) DeleteUserSessions(_ context.Context, userID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for sessionID, stored := range r.sessions {
+		if stored.session.UserID == userID {
+			delete(r.sessions, sessionID)
+		}
+	}
+	return nil
+}
+
+// find returns the session unless it is missing or has expired, in which
+// case it is dropped. The caller must hold the lock.
+func (r *SessionRepository) find(sessionID domain.ID) (*storedSession, bool) {
+	stored, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	if stored.expiresTime != nil && !time.Now().Before(*stored.expiresTime) {
+		delete(r.sessions, sessionID)
+		return nil, false
+	}
+	return stored, true
+}
+
+// update applies change to the session under the lock and rebuilds its
+// list of chat names.
+func (r *SessionRepository) update(sessionID domain.ID, change func(*storedSession)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.find(sessionID)
+	if !ok {
+		return repositories.ErrSessionNotFound
+	}
+	change(stored)
+	stored.session.ChatNameList = chatNames(stored.session.ChatIDAndName)
+	return nil
+}
+
+func chatNames(chatIDAndName map[string]string) []string {
+	var names []string
+	for _, name := range chatIDAndName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cloneSession(session domain.Session) domain.Session {
+	session.ChatIDAndName = maps.Clone(session.ChatIDAndName)
+	session.ChatNameList = slices.Clone(session.ChatNameList)
+	return session
+}
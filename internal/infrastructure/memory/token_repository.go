@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"chat-app/internal/core/repositories"
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenRepository implements repositories.TokenRepository. Records of
+// expired tokens are dropped as new ones are marked.
+type TokenRepository struct {
+	mu   sync.Mutex
+	used map[string]time.Time // tokenID -> expiresTime
+}
+
+func NewTokenRepository() *TokenRepository {
+	return &TokenRepository{used: make(map[string]time.Time)}
+}
+
+func (r *TokenRepository) MarkTokenUsed(_ context.Context, tokenID string, expiresTime time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, expires := range r.used {
+		if expires.Before(now) {
+			delete(r.used, id)
+		}
+	}
+	if _, ok := r.used[tokenID]; ok {
+		return repositories.ErrTokenUsed
+	}
+	r.used[tokenID] = expiresTime
+	return nil
+}
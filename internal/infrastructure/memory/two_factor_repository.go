@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"slices"
+	"sync"
+)
+
+// TwoFactorRepository implements repositories.TwoFactorRepository.
+type TwoFactorRepository struct {
+	mu         sync.Mutex
+	twoFactors map[domain.ID]domain.TwoFactor
+	challenges map[domain.ID]domain.LoginChallenge
+}
+
+func NewTwoFactorRepository() *TwoFactorRepository {
+	return &TwoFactorRepository{
+		twoFactors: make(map[domain.ID]domain.TwoFactor),
+		challenges: make(map[domain.ID]domain.LoginChallenge),
+	}
+}
+
+func (r *TwoFactorRepository) FindTwoFactor(_ context.Context, userID domain.ID) (domain.TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok {
+		return domain.TwoFactor{}, repositories.ErrTwoFactorNotFound
+	}
+	twoFactor.RecoveryCodes = slices.Clone(twoFactor.RecoveryCodes)
+	return twoFactor, nil
+}
+
+func (r *TwoFactorRepository) SaveTwoFactor(_ context.Context, twoFactor domain.TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor.RecoveryCodes = slices.Clone(twoFactor.RecoveryCodes)
+	r.twoFactors[twoFactor.UserID] = twoFactor
+	return nil
+}
+
+func (r *TwoFactorRepository) DeleteTwoFactor(_ context.Context, userID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.twoFactors, userID)
+	return nil
+}
+
+func (r *TwoFactorRepository) CreateChallenge(_ context.Context, challenge domain.LoginChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (r *TwoFactorRepository) FindChallenge(_ context.Context, challengeID domain.ID) (domain.LoginChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.challenges[challengeID]
+	if !ok {
+		return domain.LoginChallenge{}, repositories.ErrChallengeNotFound
+	}
+	return challenge, nil
+}
+
+func (r *TwoFactorRepository) UpdateChallenge(_ context.Context, challenge domain.LoginChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.challenges[challenge.ID]; !ok {
+		return repositories.ErrChallengeNotFound
+	}
+	r.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (r *TwoFactorRepository) DeleteChallenge(_ context.Context, challengeID domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.challenges, challengeID)
+	return nil
+}
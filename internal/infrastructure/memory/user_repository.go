@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserRepository implements repositories.UserRepository. Chat lists are
+// read from the ChatRepository the users are stored with. Passwords are
+// kept as hashes apart from the user records, which never carry them.
+type UserRepository struct {
+	mu        sync.Mutex
+	users     map[domain.ID]domain.User
+	passwords map[domain.ID][sha256.Size]byte
+	chats     *ChatRepository
+}
+
+func NewUserRepository(chats *ChatRepository) *UserRepository {
+	return &UserRepository{
+		users:     make(map[domain.ID]domain.User),
+		passwords: make(map[domain.ID][sha256.Size]byte),
+		chats:     chats,
+	}
+}
+
+func (r *UserRepository) Register(_ context.Context, user domain.User) (domain.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID]; ok {
+		return "", repositories.ErrDuplicateUser
+	}
+	if r.taken(user) {
+		return "", repositories.ErrDuplicateUser
+	}
+	r.passwords[user.ID] = sha256.Sum256([]byte(user.Password))
+	user.Password = ""
+	r.users[user.ID] = cloneUser(user)
+	return user.ID, nil
+}
+
+func (r *UserRepository) Login(_ context.Context, username, password string) (domain.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.findBy(func(user domain.User) bool { return strings.EqualFold(user.Username, username) })
+	if !ok {
+		return "", repositories.ErrWrongLoginInfo
+	}
+	hash := sha256.Sum256([]byte(password))
+	stored := r.passwords[user.ID]
+	if subtle.ConstantTimeCompare(hash[:], stored[:]) != 1 {
+		return "", repositories.ErrWrongLoginInfo
+	}
+	return user.ID, nil
+}
+
+func (r *UserRepository) GetChatIDList(_ context.Context, userID domain.ID) ([]string, error) {
+	r.mu.Lock()
+	_, ok := r.users[userID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, repositories.ErrUserNotFound
+	}
+	return r.chats.chatIDsOf(userID), nil
+}
+
+func (r *UserRepository) GetUserInfo(_ context.Context, userID domain.ID) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.User{}, repositories.ErrUserNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *UserRepository) FindUserByUsername(_ context.Context, username string) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.findBy(func(user domain.User) bool { return strings.EqualFold(user.Username, username) })
+	if !ok {
+		return domain.User{}, repositories.ErrUserNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *UserRepository) FindUserByEmail(_ context.Context, email string) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.findBy(func(user domain.User) bool { return user.Email != "" && strings.EqualFold(user.Email, email) })
+	if !ok {
+		return domain.User{}, repositories.ErrUserNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *UserRepository) SearchUsers(_ context.Context, text string, limit, offset int) ([]domain.User, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	words := strings.Fields(text)
+
+	r.mu.Lock()
+	var matches []domain.User
+	for _, user := range r.users {
+		if matchesSearch(user, text, words) {
+			matches = append(matches, cloneUser(user))
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Username < matches[j].Username })
+	if offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[offset:]
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func matchesSearch(user domain.User, text string, words []string) bool {
+	if strings.HasPrefix(strings.ToLower(user.Username), text) {
+		return true
+	}
+	for _, word := range words {
+		if strings.HasPrefix(strings.ToLower(user.FirstName), word) || strings.HasPrefix(strings.ToLower(user.LastName), word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserRepository) UpdateUser(_ context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	if r.taken(user) {
+		return repositories.ErrDuplicateUser
+	}
+	user.Password = ""
+	user.PasswordChangedTime = stored.PasswordChangedTime
+	r.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *UserRepository) UpdatePassword(_ context.Context, userID domain.ID, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	now := time.Now()
+	user.PasswordChangedTime = &now
+	r.users[userID] = user
+	r.passwords[userID] = sha256.Sum256([]byte(password))
+	return nil
+}
+
+func (r *UserRepository) BlockUser(_ context.Context, userID, blockedID domain.ID) error {
+	return r.update(userID, func(user *domain.User) {
+		if !slices.Contains(user.Blocked, blockedID) {
+			user.Blocked = append(user.Blocked, blockedID)
+		}
+	})
+}
+
+func (r *UserRepository) UnblockUser(_ context.Context, userID, blockedID domain.ID) error {
+	return r.update(userID, func(user *domain.User) {
+		user.Blocked = slices.DeleteFunc(user.Blocked, func(id domain.ID) bool { return id == blockedID })
+	})
+}
+
+func (r *UserRepository) SetDeletedTime(_ context.Context, userID domain.ID, deletedTime *time.Time) error {
+	return r.update(userID, func(user *domain.User) {
+		user.DeletedTime = deletedTime
+	})
+}
+
+func (r *UserRepository) ListDeletedUsers(_ context.Context, deletedBefore time.Time) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domain.User
+	for _, user := range r.users {
+		if user.DeletedTime != nil && user.DeletedTime.Before(deletedBefore) && user.AnonymizedTime == nil {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// update applies change to the stored user under the lock.
+func (r *UserRepository) update(userID domain.ID, change func(*domain.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[userID]
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	user := cloneUser(stored)
+	change(&user)
+	r.users[userID] = user
+	return nil
+}
+
+func (r *UserRepository) findBy(match func(domain.User) bool) (domain.User, bool) {
+	for _, user := range r.users {
+		if match(user) {
+			return user, true
+		}
+	}
+	return domain.User{}, false
+}
+
+// taken reports whether another user already has the user's username or
+// email.
+func (r *UserRepository) taken(user domain.User) bool {
+	_, ok := r.findBy(func(other domain.User) bool {
+		if other.ID == user.ID {
+			return false
+		}
+		return strings.EqualFold(other.Username, user.Username) ||
+			(user.Email != "" && strings.EqualFold(other.Email, user.Email))
+	})
+	return ok
+}
+
+func cloneUser(user domain.User) domain.User {
+	user.Contacts = slices.Clone(user.Contacts)
+	user.Blocked = slices.Clone(user.Blocked)
+	return user
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"chat-app/internal/core/domain"
+	"chat-app/internal/core/repositories"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateUserKeepsPassword(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserRepository(NewChatRepository(NewMessageRepository()))
+	for _, user := range []domain.User{
+		{ID: "alice", Username: "alice", Email: "alice@example.com", Password: "first password"},
+		{ID: "bob", Username: "bob", Email: "bob@example.com", Password: "bob's password"},
+	} {
+		if _, err := users.Register(ctx, user); err != nil {
+			t.Fatalf("Register %s: %v", user.ID, err)
+		}
+	}
+
+	stored, err := users.GetUserInfo(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password != "" {
+		t.Fatal("GetUserInfo returned the password")
+	}
+
+	stored.FirstName = "Alice"
+	if err = users.UpdateUser(ctx, stored); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if _, err = users.Login(ctx, "alice", "first password"); err != nil {
+		t.Fatalf("Login after a blank-password update: %v", err)
+	}
+	if _, err = users.Login(ctx, "alice", ""); !errors.Is(err, repositories.ErrWrongLoginInfo) {
+		t.Fatalf("Login with a blank password = %v, want ErrWrongLoginInfo", err)
+	}
+
+	stored.Password = "smuggled password"
+	if err = users.UpdateUser(ctx, stored); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if _, err = users.Login(ctx, "alice", "smuggled password"); !errors.Is(err, repositories.ErrWrongLoginInfo) {
+		t.Fatalf("UpdateUser changed the password: Login = %v", err)
+	}
+
+	if err = users.UpdatePassword(ctx, "alice", "second password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = users.Login(ctx, "alice", "second password"); err != nil {
+		t.Fatalf("Login after UpdatePassword: %v", err)
+	}
+	changed, err := users.GetUserInfo(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.PasswordChangedTime == nil {
+		t.Fatal("UpdatePassword did not set PasswordChangedTime")
+	}
+	if err = users.UpdateUser(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if updated, _ := users.GetUserInfo(ctx, "alice"); !reflect.DeepEqual(updated.PasswordChangedTime, changed.PasswordChangedTime) {
+		t.Fatalf("UpdateUser changed PasswordChangedTime to %v", updated.PasswordChangedTime)
+	}
+
+	stored.Email = "BOB@example.com"
+	if err = users.UpdateUser(ctx, stored); !errors.Is(err, repositories.ErrDuplicateUser) {
+		t.Fatalf("UpdateUser with a taken email = %v, want ErrDuplicateUser", err)
+	}
+}